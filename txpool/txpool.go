@@ -0,0 +1,347 @@
+// Package txpool implements an in-memory pool of Shutter transactions, indexed
+// by the batch they are encrypted for and by their sender.
+package txpool
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/shutter-network/txtypes/types"
+)
+
+var (
+	// ErrAlreadyKnown is returned if the transaction is already contained
+	// within the pool.
+	ErrAlreadyKnown = errors.New("already known")
+
+	// ErrReplaceUnderpriced is returned if a transaction is attempted to be
+	// replaced with a different one without the required price bump.
+	ErrReplaceUnderpriced = errors.New("replacement transaction underpriced")
+
+	// ErrBatchExpired is returned if the batch of the transaction has already
+	// been evicted from the pool.
+	ErrBatchExpired = errors.New("batch expired")
+
+	// ErrTxPoolOverflow is returned if the transaction would exceed the size
+	// limit of the pool.
+	ErrTxPoolOverflow = errors.New("txpool is full")
+)
+
+// Config are the configuration parameters of the transaction pool.
+type Config struct {
+	PriceBump uint64             // Minimum price bump percentage to replace an already existing transaction (nonce)
+	MaxSize   common.StorageSize // Maximum total size of all pooled transactions
+}
+
+// DefaultConfig contains the default configurations for the transaction pool.
+var DefaultConfig = Config{
+	PriceBump: 10,
+	MaxSize:   64 * 1024 * 1024,
+}
+
+// sanitize checks the provided user configurations and changes anything that's
+// unreasonable or unworkable.
+func (config *Config) sanitize() Config {
+	conf := *config
+	if conf.PriceBump < 1 {
+		conf.PriceBump = DefaultConfig.PriceBump
+	}
+	if conf.MaxSize < 1 {
+		conf.MaxSize = DefaultConfig.MaxSize
+	}
+	return conf
+}
+
+// TxPool holds Shutter transactions by batch index and sender until they are
+// included in a batch. It is safe for concurrent use.
+//
+// Nonces are tracked per sender across all batches: a sender has at most one
+// pooled transaction per nonce, and a transaction with the nonce of a pooled one
+// replaces it even if it is encrypted for another batch.
+type TxPool struct {
+	config Config
+	signer types.Signer
+	rules  params.Rules
+
+	mu      sync.RWMutex
+	senders map[common.Address]map[uint64]*types.Transaction // Transactions by sender and nonce
+	batches map[uint64]map[common.Hash]*types.Transaction    // Transactions by batch index
+	all     map[common.Hash]*types.Transaction               // All transactions to allow lookups
+	size    common.StorageSize                               // Total size of all transactions
+	evicted uint64                                           // Batches below this index are evicted
+}
+
+// New creates a transaction pool. The signer is used to derive the senders of
+// the transactions, and the transactions must cover their intrinsic gas under
+// the given rules.
+func New(config Config, signer types.Signer, rules params.Rules) *TxPool {
+	return &TxPool{
+		config:  config.sanitize(),
+		signer:  signer,
+		rules:   rules,
+		senders: make(map[common.Address]map[uint64]*types.Transaction),
+		batches: make(map[uint64]map[common.Hash]*types.Transaction),
+		all:     make(map[common.Hash]*types.Transaction),
+	}
+}
+
+// Add adds a Shutter transaction to the pool. A pooled transaction of the same
+// sender with the same nonce is replaced if the new one bumps both its fee cap
+// and tip cap by the configured percentage.
+func (pool *TxPool) Add(tx *types.Transaction) error {
+	if tx.Type() != types.ShutterTxType {
+		return types.ErrInvalidTxType
+	}
+	from, err := types.Sender(pool.signer, tx)
+	if err != nil {
+		return err
+	}
+	if err := types.ValidateIntrinsicGas(tx, pool.rules); err != nil {
+		return err
+	}
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if _, ok := pool.all[tx.Hash()]; ok {
+		return ErrAlreadyKnown
+	}
+	if tx.BatchIndex() < pool.evicted {
+		return ErrBatchExpired
+	}
+	old := pool.senders[from][tx.Nonce()]
+	if old != nil && !pool.replaces(tx, old) {
+		return ErrReplaceUnderpriced
+	}
+	size := pool.size + tx.Size()
+	if old != nil {
+		size -= old.Size()
+	}
+	if size > pool.config.MaxSize {
+		return ErrTxPoolOverflow
+	}
+	if old != nil {
+		pool.remove(old)
+	}
+
+	txs, ok := pool.senders[from]
+	if !ok {
+		txs = make(map[uint64]*types.Transaction)
+		pool.senders[from] = txs
+	}
+	txs[tx.Nonce()] = tx
+	batch, ok := pool.batches[tx.BatchIndex()]
+	if !ok {
+		batch = make(map[common.Hash]*types.Transaction)
+		pool.batches[tx.BatchIndex()] = batch
+	}
+	batch[tx.Hash()] = tx
+	pool.all[tx.Hash()] = tx
+	pool.size += tx.Size()
+	return nil
+}
+
+// replaces returns whether tx is priced high enough to replace old.
+func (pool *TxPool) replaces(tx, old *types.Transaction) bool {
+	if old.GasFeeCapCmp(tx) >= 0 || old.GasTipCapCmp(tx) >= 0 {
+		return false
+	}
+	// thresholdFeeCap = oldFC  * (100 + priceBump) / 100
+	a := big.NewInt(100 + int64(pool.config.PriceBump))
+	aFeeCap := new(big.Int).Mul(a, old.GasFeeCap())
+	aTip := a.Mul(a, old.GasTipCap())
+
+	// thresholdTip    = oldTip * (100 + priceBump) / 100
+	b := big.NewInt(100)
+	thresholdFeeCap := aFeeCap.Div(aFeeCap, b)
+	thresholdTip := aTip.Div(aTip, b)
+
+	return tx.GasFeeCapIntCmp(thresholdFeeCap) >= 0 && tx.GasTipCapIntCmp(thresholdTip) >= 0
+}
+
+// Get returns the transaction with the given hash, or nil if it is not pooled.
+func (pool *TxPool) Get(hash common.Hash) *types.Transaction {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	return pool.all[hash]
+}
+
+// Remove removes the transaction with the given hash, e.g. once it is included
+// in a batch. It returns whether the transaction was pooled.
+func (pool *TxPool) Remove(hash common.Hash) bool {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	tx, ok := pool.all[hash]
+	if ok {
+		pool.remove(tx)
+	}
+	return ok
+}
+
+// remove removes a pooled transaction. The caller must hold the write lock.
+func (pool *TxPool) remove(tx *types.Transaction) {
+	from, _ := types.Sender(pool.signer, tx) // already validated
+	if txs := pool.senders[from]; txs[tx.Nonce()] == tx {
+		delete(txs, tx.Nonce())
+		if len(txs) == 0 {
+			delete(pool.senders, from)
+		}
+	}
+	if batch := pool.batches[tx.BatchIndex()]; batch != nil {
+		delete(batch, tx.Hash())
+		if len(batch) == 0 {
+			delete(pool.batches, tx.BatchIndex())
+		}
+	}
+	delete(pool.all, tx.Hash())
+	pool.size -= tx.Size()
+}
+
+// Evict removes the transactions of all batches before the given batch index
+// and rejects transactions for them from now on. It returns the number of
+// removed transactions.
+func (pool *TxPool) Evict(batchIndex uint64) int {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if batchIndex <= pool.evicted {
+		return 0
+	}
+	pool.evicted = batchIndex
+
+	removed := 0
+	for index, batch := range pool.batches {
+		if index >= batchIndex {
+			continue
+		}
+		for _, tx := range batch {
+			pool.remove(tx)
+			removed++
+		}
+	}
+	return removed
+}
+
+// Pending returns the transactions of the batch which are executable in nonce
+// order, grouped by sender and sorted by nonce. The transactions of a sender
+// start at the nonce returned by nonceAt, or at its lowest pooled nonce if
+// nonceAt is nil. They end before the first nonce gap and before the first
+// transaction of another batch.
+func (pool *TxPool) Pending(batchIndex uint64, nonceAt func(common.Address) uint64) map[common.Address]types.Transactions {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	pending := make(map[common.Address]types.Transactions)
+	for from, txs := range pool.senders {
+		if run, _ := splitExecutable(txs, startNonce(from, txs, nonceAt), batchIndex); len(run) > 0 {
+			pending[from] = run
+		}
+	}
+	return pending
+}
+
+// Queued returns the transactions of the batch which are not executable because
+// of a nonce gap or a preceding transaction of another batch, grouped by sender
+// and sorted by nonce.
+func (pool *TxPool) Queued(batchIndex uint64, nonceAt func(common.Address) uint64) map[common.Address]types.Transactions {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	queued := make(map[common.Address]types.Transactions)
+	for from, txs := range pool.senders {
+		if _, rest := splitExecutable(txs, startNonce(from, txs, nonceAt), batchIndex); len(rest) > 0 {
+			queued[from] = rest
+		}
+	}
+	return queued
+}
+
+// startNonce returns the nonce the executable transactions of a sender start at.
+func startNonce(from common.Address, txs map[uint64]*types.Transaction, nonceAt func(common.Address) uint64) uint64 {
+	if nonceAt != nil {
+		return nonceAt(from)
+	}
+	lowest := uint64(math.MaxUint64)
+	for nonce := range txs {
+		if nonce < lowest {
+			lowest = nonce
+		}
+	}
+	return lowest
+}
+
+// splitExecutable splits the transactions of a sender for the batch into the
+// executable run starting at the given nonce and the remaining ones, as
+// described in Pending. Transactions with lower nonces are left out.
+func splitExecutable(txs map[uint64]*types.Transaction, nonce uint64, batchIndex uint64) (run, rest types.Transactions) {
+	executable := true
+	for _, tx := range sortedByNonce(txs) {
+		if tx.Nonce() < nonce {
+			continue
+		}
+		if tx.Nonce() != nonce || tx.BatchIndex() != batchIndex {
+			executable = false
+		}
+		switch {
+		case executable:
+			run = append(run, tx)
+			nonce++
+		case tx.BatchIndex() == batchIndex:
+			rest = append(rest, tx)
+		}
+	}
+	return run, rest
+}
+
+// Fill adds the pending transactions of the batch being built to the builder,
+// ordered by price and nonce, until the limits of the batch are reached. It
+// returns the number of added transactions.
+func (pool *TxPool) Fill(builder *types.BatchBuilder, baseFee *big.Int, nonceAt func(common.Address) uint64) int {
+	pending := pool.Pending(builder.BatchIndex(), nonceAt)
+	txs := types.NewShutterTransactionsByPriceAndNonce(pool.signer, pending, baseFee, builder.BatchIndex())
+
+	added := 0
+	for tx := txs.Peek(); tx != nil; tx = txs.Peek() {
+		if err := builder.Add(tx); err != nil {
+			// Skip the remaining transactions of the sender, they can't be
+			// executed without this one.
+			txs.Pop()
+			continue
+		}
+		added++
+		txs.Shift()
+	}
+	return added
+}
+
+// Len returns the number of pooled transactions.
+func (pool *TxPool) Len() int {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	return len(pool.all)
+}
+
+// Size returns the total size of the pooled transactions.
+func (pool *TxPool) Size() common.StorageSize {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	return pool.size
+}
+
+// sortedByNonce returns the transactions sorted by nonce.
+func sortedByNonce(txs map[uint64]*types.Transaction) types.Transactions {
+	sorted := make(types.Transactions, 0, len(txs))
+	for _, tx := range txs {
+		sorted = append(sorted, tx)
+	}
+	sort.Sort(types.TxByNonce(sorted))
+	return sorted
+}
@@ -0,0 +1,347 @@
+package txpool
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/shutter-network/txtypes/types"
+)
+
+var (
+	testChainID = big.NewInt(1)
+	testSigner  = types.NewShutterSigner(testChainID)
+	testRules   = params.AllEthashProtocolChanges.Rules(common.Big0)
+)
+
+func newTestKey(t testing.TB) (*ecdsa.PrivateKey, common.Address) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return key, crypto.PubkeyToAddress(key.PublicKey)
+}
+
+// shutterTx creates a signed Shutter transaction with equal fee and tip caps.
+func shutterTx(key *ecdsa.PrivateKey, nonce, batchIndex uint64, price int64) *types.Transaction {
+	return pricedShutterTx(key, nonce, batchIndex, price, price)
+}
+
+func pricedShutterTx(key *ecdsa.PrivateKey, nonce, batchIndex uint64, feeCap, tip int64) *types.Transaction {
+	return types.MustSignNewTx(key, testSigner, &types.ShutterTx{
+		ChainID:          testChainID,
+		Nonce:            nonce,
+		GasTipCap:        big.NewInt(tip),
+		GasFeeCap:        big.NewInt(feeCap),
+		Gas:              100000,
+		EncryptedPayload: []byte{0x01, 0x02, 0x03},
+		BatchIndex:       batchIndex,
+	})
+}
+
+// validatePoolInternals checks that the indices and the size of the pool are
+// consistent.
+func validatePoolInternals(t *testing.T, pool *TxPool) {
+	t.Helper()
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	var (
+		bySender, byBatch int
+		size              common.StorageSize
+	)
+	for from, txs := range pool.senders {
+		for nonce, tx := range txs {
+			if sender, _ := types.Sender(testSigner, tx); sender != from || tx.Nonce() != nonce {
+				t.Errorf("tx %v indexed under sender %v nonce %d", tx.Hash(), from, nonce)
+			}
+			bySender++
+		}
+	}
+	for index, batch := range pool.batches {
+		for hash, tx := range batch {
+			if tx.BatchIndex() != index || tx.Hash() != hash || pool.all[hash] != tx {
+				t.Errorf("tx %v indexed under batch %d", tx.Hash(), index)
+			}
+			byBatch++
+		}
+	}
+	for _, tx := range pool.all {
+		size += tx.Size()
+	}
+	if bySender != len(pool.all) || byBatch != len(pool.all) {
+		t.Errorf("index mismatch: %d by sender, %d by batch, %d in total", bySender, byBatch, len(pool.all))
+	}
+	if size != pool.size {
+		t.Errorf("size mismatch: have %v, want %v", pool.size, size)
+	}
+}
+
+func TestAddInvalid(t *testing.T) {
+	pool := New(DefaultConfig, testSigner, testRules)
+	key, _ := newTestKey(t)
+
+	plain := types.MustSignNewTx(key, testSigner, &types.DynamicFeeTx{ChainID: testChainID, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(1), Gas: 21000})
+	if err := pool.Add(plain); !errors.Is(err, types.ErrInvalidTxType) {
+		t.Errorf("plaintext tx: wrong error: have %v, want %v", err, types.ErrInvalidTxType)
+	}
+	underpaid := types.MustSignNewTx(key, testSigner, &types.ShutterTx{ChainID: testChainID, GasTipCap: big.NewInt(100), GasFeeCap: big.NewInt(100), Gas: params.TxGas, EncryptedPayload: []byte{0x01}, BatchIndex: 1})
+	if err := pool.Add(underpaid); !errors.Is(err, types.ErrIntrinsicGas) {
+		t.Errorf("tx below intrinsic gas: wrong error: have %v, want %v", err, types.ErrIntrinsicGas)
+	}
+	tx := shutterTx(key, 0, 1, 100)
+	if err := pool.Add(tx); err != nil {
+		t.Fatalf("failed to add tx: %v", err)
+	}
+	if err := pool.Add(tx); !errors.Is(err, ErrAlreadyKnown) {
+		t.Errorf("duplicate tx: wrong error: have %v, want %v", err, ErrAlreadyKnown)
+	}
+	if pool.Get(tx.Hash()) != tx || pool.Len() != 1 {
+		t.Errorf("tx not pooled")
+	}
+	validatePoolInternals(t, pool)
+}
+
+func TestReplacementPriceBump(t *testing.T) {
+	pool := New(Config{PriceBump: 10}, testSigner, testRules)
+	key, _ := newTestKey(t)
+
+	old := shutterTx(key, 0, 1, 100)
+	if err := pool.Add(old); err != nil {
+		t.Fatalf("failed to add tx: %v", err)
+	}
+	tests := []struct {
+		feeCap, tip int64
+		batchIndex  uint64
+	}{
+		{100, 100, 2}, // same price in another batch
+		{109, 109, 1}, // below the bump
+		{110, 109, 1}, // only the fee cap bumped
+		{109, 110, 1}, // only the tip bumped
+		{109, 109, 2}, // below the bump in another batch
+	}
+	for i, tt := range tests {
+		if err := pool.Add(pricedShutterTx(key, 0, tt.batchIndex, tt.feeCap, tt.tip)); !errors.Is(err, ErrReplaceUnderpriced) {
+			t.Errorf("test %d: wrong error: have %v, want %v", i, err, ErrReplaceUnderpriced)
+		}
+	}
+	if pool.Get(old.Hash()) == nil {
+		t.Fatalf("underpriced replacement removed the old tx")
+	}
+
+	// Exactly the bump replaces the transaction, also for another batch.
+	replacement := shutterTx(key, 0, 1, 110)
+	if err := pool.Add(replacement); err != nil {
+		t.Fatalf("failed to replace tx: %v", err)
+	}
+	moved := shutterTx(key, 0, 2, 121)
+	if err := pool.Add(moved); err != nil {
+		t.Fatalf("failed to replace tx in another batch: %v", err)
+	}
+	if pool.Get(old.Hash()) != nil || pool.Get(replacement.Hash()) != nil || pool.Get(moved.Hash()) == nil || pool.Len() != 1 {
+		t.Errorf("replaced txs still pooled")
+	}
+	if pending := pool.Pending(1, nil); len(pending) != 0 {
+		t.Errorf("replaced tx still pending in its batch: %v", pending)
+	}
+	validatePoolInternals(t, pool)
+}
+
+func TestMaxSize(t *testing.T) {
+	key, _ := newTestKey(t)
+	txs := []*types.Transaction{shutterTx(key, 0, 1, 100), shutterTx(key, 1, 1, 100), shutterTx(key, 2, 1, 100)}
+	pool := New(Config{MaxSize: txs[0].Size() + txs[1].Size()}, testSigner, testRules)
+
+	for _, tx := range txs[:2] {
+		if err := pool.Add(tx); err != nil {
+			t.Fatalf("failed to add tx: %v", err)
+		}
+	}
+	if err := pool.Add(txs[2]); !errors.Is(err, ErrTxPoolOverflow) {
+		t.Fatalf("wrong error: have %v, want %v", err, ErrTxPoolOverflow)
+	}
+	// A replacement of the same size fits into the full pool.
+	if err := pool.Add(shutterTx(key, 1, 1, 110)); err != nil {
+		t.Fatalf("failed to replace tx in full pool: %v", err)
+	}
+	if !pool.Remove(txs[0].Hash()) {
+		t.Fatalf("failed to remove tx")
+	}
+	if err := pool.Add(txs[2]); err != nil {
+		t.Fatalf("failed to add tx after removal: %v", err)
+	}
+	if pool.Size() != pool.config.MaxSize {
+		t.Errorf("wrong size: have %v, want %v", pool.Size(), pool.config.MaxSize)
+	}
+	validatePoolInternals(t, pool)
+}
+
+func TestEvict(t *testing.T) {
+	pool := New(DefaultConfig, testSigner, testRules)
+	key, _ := newTestKey(t)
+	for nonce := uint64(0); nonce < 6; nonce++ {
+		if err := pool.Add(shutterTx(key, nonce, 1+nonce/2, 100)); err != nil {
+			t.Fatalf("failed to add tx: %v", err)
+		}
+	}
+	if n := pool.Evict(3); n != 4 {
+		t.Fatalf("wrong number of evicted txs: have %d, want 4", n)
+	}
+	if n := pool.Evict(2); n != 0 {
+		t.Fatalf("evicted %d txs of an evicted batch", n)
+	}
+	if pool.Len() != 2 {
+		t.Fatalf("wrong number of txs: have %d, want 2", pool.Len())
+	}
+	for _, batchIndex := range []uint64{1, 2} {
+		if err := pool.Add(shutterTx(key, 10, batchIndex, 100)); !errors.Is(err, ErrBatchExpired) {
+			t.Errorf("batch %d: wrong error: have %v, want %v", batchIndex, err, ErrBatchExpired)
+		}
+	}
+	if err := pool.Add(shutterTx(key, 10, 3, 100)); err != nil {
+		t.Errorf("failed to add tx for current batch: %v", err)
+	}
+	validatePoolInternals(t, pool)
+}
+
+func TestPendingAndQueued(t *testing.T) {
+	pool := New(DefaultConfig, testSigner, testRules)
+	keyA, addrA := newTestKey(t)
+	keyB, addrB := newTestKey(t)
+
+	for _, tx := range []*types.Transaction{
+		// Sender A: nonces 0 and 1 for batch 1, 2 for batch 2, 4 for batch 1
+		// after a gap.
+		shutterTx(keyA, 0, 1, 100),
+		shutterTx(keyA, 1, 1, 100),
+		shutterTx(keyA, 2, 2, 100),
+		shutterTx(keyA, 4, 1, 100),
+		// Sender B: nonce 5 for batch 2 before nonce 6 for batch 1.
+		shutterTx(keyB, 5, 2, 100),
+		shutterTx(keyB, 6, 1, 100),
+	} {
+		if err := pool.Add(tx); err != nil {
+			t.Fatalf("failed to add tx: %v", err)
+		}
+	}
+	nonces := func(txs types.Transactions) []uint64 {
+		var nonces []uint64
+		for _, tx := range txs {
+			nonces = append(nonces, tx.Nonce())
+		}
+		return nonces
+	}
+	check := func(name string, have map[common.Address]types.Transactions, want map[common.Address][]uint64) {
+		t.Helper()
+		if len(have) != len(want) {
+			t.Errorf("%s: wrong senders: have %d, want %d", name, len(have), len(want))
+		}
+		for from, w := range want {
+			if h := nonces(have[from]); fmt.Sprint(h) != fmt.Sprint(w) {
+				t.Errorf("%s: wrong nonces of %v: have %v, want %v", name, from, h, w)
+			}
+		}
+	}
+	check("pending 1", pool.Pending(1, nil), map[common.Address][]uint64{addrA: {0, 1}})
+	check("queued 1", pool.Queued(1, nil), map[common.Address][]uint64{addrA: {4}, addrB: {6}})
+	check("pending 2", pool.Pending(2, nil), map[common.Address][]uint64{addrB: {5}})
+	check("queued 2", pool.Queued(2, nil), map[common.Address][]uint64{addrA: {2}})
+
+	// Once batch 1 is executed, the nonces of the state take over.
+	state := map[common.Address]uint64{addrA: 2, addrB: 5}
+	nonceAt := func(addr common.Address) uint64 { return state[addr] }
+	check("pending 2 after batch 1", pool.Pending(2, nonceAt), map[common.Address][]uint64{addrA: {2}, addrB: {5}})
+	state[addrB] = 6
+	check("pending 1 at nonce 6", pool.Pending(1, nonceAt), map[common.Address][]uint64{addrB: {6}})
+}
+
+func TestFill(t *testing.T) {
+	pool := New(DefaultConfig, testSigner, testRules)
+	keyA, _ := newTestKey(t)
+	keyB, _ := newTestKey(t)
+	for _, tx := range []*types.Transaction{
+		shutterTx(keyA, 0, 1, 100),
+		shutterTx(keyA, 1, 1, 100),
+		shutterTx(keyA, 3, 1, 100), // behind a gap
+		shutterTx(keyB, 0, 1, 200),
+		shutterTx(keyB, 1, 2, 200), // another batch
+	} {
+		if err := pool.Add(tx); err != nil {
+			t.Fatalf("failed to add tx: %v", err)
+		}
+	}
+	builder := types.NewBatchBuilder(testSigner, testRules, 1, 0, big.NewInt(0), 3*100000, 1024*1024)
+	if n := pool.Fill(builder, nil, nil); n != 3 {
+		t.Fatalf("wrong number of added txs: have %d, want 3", n)
+	}
+	txs := builder.Transactions()
+	if len(txs) != 3 {
+		t.Fatalf("wrong number of batch txs: have %d, want 3", len(txs))
+	}
+	for _, tx := range txs {
+		if tx.BatchIndex() != 1 {
+			t.Errorf("tx of batch %d added", tx.BatchIndex())
+		}
+	}
+
+	// Transactions exceeding the gas limit of the batch are left out.
+	builder = types.NewBatchBuilder(testSigner, testRules, 1, 0, big.NewInt(0), 100000, 1024*1024)
+	if n := pool.Fill(builder, nil, nil); n != 1 {
+		t.Fatalf("wrong number of added txs at gas limit: have %d, want 1", n)
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	pool := New(DefaultConfig, testSigner, testRules)
+
+	const (
+		senders = 8
+		txs     = 32
+	)
+	var wg sync.WaitGroup
+	for i := 0; i < senders; i++ {
+		key, _ := newTestKey(t)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for nonce := uint64(0); nonce < txs; nonce++ {
+				tx := shutterTx(key, nonce, nonce%4, 100)
+				pool.Add(tx)
+				pool.Get(tx.Hash())
+				if nonce%3 == 0 {
+					pool.Remove(tx.Hash())
+				}
+				pool.Add(shutterTx(key, nonce, nonce%4, 110))
+			}
+		}()
+	}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(batchIndex uint64) {
+			defer wg.Done()
+			for j := 0; j < txs; j++ {
+				pool.Pending(batchIndex, nil)
+				pool.Queued(batchIndex, nil)
+				pool.Fill(types.NewBatchBuilder(testSigner, testRules, batchIndex, 0, big.NewInt(0), 1000000, 1024*1024), nil, nil)
+				pool.Len()
+				pool.Size()
+			}
+		}(uint64(i))
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for batchIndex := uint64(0); batchIndex < 3; batchIndex++ {
+			pool.Evict(batchIndex)
+		}
+	}()
+	wg.Wait()
+
+	validatePoolInternals(t, pool)
+}
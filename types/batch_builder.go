@@ -0,0 +1,149 @@
+package types
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	ErrBatchGasLimitReached  = errors.New("batch gas limit reached")
+	ErrBatchSizeLimitReached = errors.New("batch size limit reached")
+	ErrBatchDuplicateTx      = errors.New("transaction already in batch")
+)
+
+// BatchBuilder assembles a batch transaction from candidate transactions while
+// respecting the gas and size limits of the batch.
+type BatchBuilder struct {
+	signer        Signer
+	rules         params.Rules
+	batchIndex    uint64
+	l1BlockNumber uint64
+	timestamp     *big.Int
+	gasLimit      uint64
+	sizeLimit     common.StorageSize
+
+	txs    []*batchCandidate
+	hashes map[common.Hash]struct{}
+	gas    uint64
+	size   common.StorageSize
+}
+
+// batchCandidate is a transaction accepted by the BatchBuilder.
+type batchCandidate struct {
+	tx   *Transaction
+	from common.Address
+}
+
+// NewBatchBuilder creates a builder for the batch with the given index. The
+// signer is used to derive the senders of the candidate transactions and to sign
+// the resulting batch transaction. The candidate transactions must cover their
+// intrinsic gas under the given rules.
+func NewBatchBuilder(signer Signer, rules params.Rules, batchIndex, l1BlockNumber uint64, timestamp *big.Int, gasLimit uint64, sizeLimit common.StorageSize) *BatchBuilder {
+	return &BatchBuilder{
+		signer:        signer,
+		rules:         rules,
+		batchIndex:    batchIndex,
+		l1BlockNumber: l1BlockNumber,
+		timestamp:     new(big.Int).Set(timestamp),
+		gasLimit:      gasLimit,
+		sizeLimit:     sizeLimit,
+		hashes:        make(map[common.Hash]struct{}),
+	}
+}
+
+// BatchIndex returns the index of the batch being built.
+func (b *BatchBuilder) BatchIndex() uint64 { return b.batchIndex }
+
+// Len returns the number of transactions added to the batch.
+func (b *BatchBuilder) Len() int { return len(b.txs) }
+
+// Gas returns the sum of the gas limits of the transactions added to the batch.
+func (b *BatchBuilder) Gas() uint64 { return b.gas }
+
+// Size returns the sum of the sizes of the transactions added to the batch.
+func (b *BatchBuilder) Size() common.StorageSize { return b.size }
+
+// Add adds a candidate transaction to the batch. If the transaction is not
+// allowed in the batch or exceeds one of its limits, an error is returned and
+// the batch is left unchanged.
+func (b *BatchBuilder) Add(tx *Transaction) error {
+	switch tx.Type() {
+	case BatchTxType:
+		return ErrInvalidTxType
+	case ShutterTxType:
+		if tx.BatchIndex() != b.batchIndex {
+			return ErrWrongBatchIndex
+		}
+	}
+	if _, ok := b.hashes[tx.Hash()]; ok {
+		return ErrBatchDuplicateTx
+	}
+	from, err := Sender(b.signer, tx)
+	if err != nil {
+		return err
+	}
+	if err := ValidateIntrinsicGas(tx, b.rules); err != nil {
+		return err
+	}
+	if tx.Gas() > b.gasLimit-b.gas {
+		return ErrBatchGasLimitReached
+	}
+	if tx.Size() > b.sizeLimit-b.size {
+		return ErrBatchSizeLimitReached
+	}
+	b.txs = append(b.txs, &batchCandidate{tx: tx, from: from})
+	b.hashes[tx.Hash()] = struct{}{}
+	b.gas += tx.Gas()
+	b.size += tx.Size()
+	return nil
+}
+
+// Transactions returns the transactions of the batch in the order they are
+// included in the batch transaction: sorted by sender, and by nonce for each
+// sender.
+func (b *BatchBuilder) Transactions() Transactions {
+	sort.SliceStable(b.txs, func(i, j int) bool {
+		x, y := b.txs[i], b.txs[j]
+		if cmp := bytes.Compare(x.from[:], y.from[:]); cmp != 0 {
+			return cmp < 0
+		}
+		if x.tx.Nonce() != y.tx.Nonce() {
+			return x.tx.Nonce() < y.tx.Nonce()
+		}
+		hx, hy := x.tx.Hash(), y.tx.Hash()
+		return bytes.Compare(hx[:], hy[:]) < 0
+	})
+	txs := make(Transactions, len(b.txs))
+	for i, c := range b.txs {
+		txs[i] = c.tx
+	}
+	return txs
+}
+
+// Build creates the batch transaction with the given decryption key and signs
+// it with the private key.
+func (b *BatchBuilder) Build(prv *ecdsa.PrivateKey, decryptionKey []byte) (*Transaction, error) {
+	txs := b.Transactions()
+	raw := make([][]byte, len(txs))
+	for i, tx := range txs {
+		enc, err := tx.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		raw[i] = enc
+	}
+	return SignNewTx(prv, b.signer, &BatchTx{
+		ChainID:       b.signer.ChainID(),
+		DecryptionKey: decryptionKey,
+		BatchIndex:    b.batchIndex,
+		L1BlockNumber: b.l1BlockNumber,
+		Timestamp:     b.timestamp,
+		Transactions:  raw,
+	})
+}
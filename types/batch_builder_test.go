@@ -0,0 +1,176 @@
+package types
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// testRules are the rules of the test chain.
+var testRules = testShutterConfig.Rules(common.Big0)
+
+// newBuilderTestTx creates a signed Shutter transaction for the batch.
+func newBuilderTestTx(key *ecdsa.PrivateKey, nonce, batchIndex uint64) *Transaction {
+	return MustSignNewTx(key, NewShutterSigner(testChainID), &ShutterTx{
+		ChainID:          testChainID,
+		Nonce:            nonce,
+		GasTipCap:        big.NewInt(1),
+		GasFeeCap:        big.NewInt(10),
+		Gas:              100000,
+		EncryptedPayload: []byte{0x01, 0x02, 0x03},
+		BatchIndex:       batchIndex,
+	})
+}
+
+func TestBatchBuilderLimits(t *testing.T) {
+	signer := NewShutterSigner(testChainID)
+	txs := Transactions{newBuilderTestTx(testKey, 0, 5), newBuilderTestTx(testKey, 1, 5), newBuilderTestTx(testKey, 2, 5)}
+
+	// The gas limit fits two transactions.
+	b := NewBatchBuilder(signer, testRules, 5, 7, big.NewInt(1000), 250000, 1024*1024)
+	for _, tx := range txs[:2] {
+		if err := b.Add(tx); err != nil {
+			t.Fatalf("failed to add tx: %v", err)
+		}
+	}
+	if err := b.Add(txs[2]); err != ErrBatchGasLimitReached {
+		t.Fatalf("wrong error: have %v, want %v", err, ErrBatchGasLimitReached)
+	}
+	if b.Len() != 2 || b.Gas() != 200000 || b.Size() != txs[0].Size()+txs[1].Size() {
+		t.Errorf("rejected tx changed the batch: %d txs, gas %d, size %v", b.Len(), b.Gas(), b.Size())
+	}
+
+	// The size limit fits two transactions.
+	b = NewBatchBuilder(signer, testRules, 5, 7, big.NewInt(1000), 1000000, txs[0].Size()+txs[1].Size())
+	for _, tx := range txs[:2] {
+		if err := b.Add(tx); err != nil {
+			t.Fatalf("failed to add tx: %v", err)
+		}
+	}
+	if err := b.Add(txs[2]); err != ErrBatchSizeLimitReached {
+		t.Fatalf("wrong error: have %v, want %v", err, ErrBatchSizeLimitReached)
+	}
+	if b.Len() != 2 || b.Gas() != 200000 {
+		t.Errorf("rejected tx changed the batch: %d txs, gas %d", b.Len(), b.Gas())
+	}
+}
+
+func TestBatchBuilderRejects(t *testing.T) {
+	signer := NewShutterSigner(testChainID)
+	b := NewBatchBuilder(signer, testRules, 5, 7, big.NewInt(1000), 1000000, 1024*1024)
+
+	tx := newBuilderTestTx(testKey, 0, 5)
+	if err := b.Add(tx); err != nil {
+		t.Fatalf("failed to add tx: %v", err)
+	}
+	tests := []struct {
+		tx  *Transaction
+		err error
+	}{
+		{tx, ErrBatchDuplicateTx},
+		{newBuilderTestTx(testKey, 1, 4), ErrWrongBatchIndex},
+		{newBuilderTestTx(testKey, 1, 6), ErrWrongBatchIndex},
+		{MustSignNewTx(testKey, signer, &BatchTx{ChainID: testChainID, BatchIndex: 5, Timestamp: big.NewInt(0)}), ErrInvalidTxType},
+		{MustSignNewTx(testKey, NewShutterSigner(big.NewInt(2)), &DynamicFeeTx{ChainID: big.NewInt(2), GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(1), Gas: 21000}), ErrInvalidChainId},
+	}
+	for i, tt := range tests {
+		if err := b.Add(tt.tx); err != tt.err {
+			t.Errorf("test %d: wrong error: have %v, want %v", i, err, tt.err)
+		}
+	}
+	if b.Len() != 1 {
+		t.Errorf("rejected txs added: have %d txs, want 1", b.Len())
+	}
+}
+
+func TestBatchBuilderIntrinsicGas(t *testing.T) {
+	signer := NewShutterSigner(testChainID)
+	b := NewBatchBuilder(signer, testRules, 5, 7, big.NewInt(1000), 1000000, 1024*1024)
+
+	for _, inner := range []TxInner{
+		&ShutterTx{ChainID: testChainID, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(10), Gas: params.TxGas, EncryptedPayload: []byte{0x01}, BatchIndex: 5},
+		&DynamicFeeTx{ChainID: testChainID, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(1), Gas: params.TxGas - 1, To: &testRecipient},
+	} {
+		tx := MustSignNewTx(testKey, signer, inner)
+		if err := b.Add(tx); !errors.Is(err, ErrIntrinsicGas) {
+			t.Errorf("type %#x: wrong error: have %v, want %v", tx.Type(), err, ErrIntrinsicGas)
+		}
+	}
+	if b.Len() != 0 || b.Gas() != 0 {
+		t.Errorf("rejected txs added: %d txs, gas %d", b.Len(), b.Gas())
+	}
+}
+
+func TestBatchBuilderOrder(t *testing.T) {
+	signer := NewShutterSigner(testChainID)
+	keyB, _ := crypto.GenerateKey()
+	txs := Transactions{
+		newBuilderTestTx(testKey, 0, 5),
+		newBuilderTestTx(testKey, 1, 5),
+		newBuilderTestTx(testKey, 2, 5),
+		newBuilderTestTx(keyB, 0, 5),
+		newBuilderTestTx(keyB, 1, 5),
+		MustSignNewTx(keyB, signer, &DynamicFeeTx{ChainID: testChainID, Nonce: 2, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(1), Gas: 21000, To: &testRecipient, Value: big.NewInt(0)}),
+	}
+	// Builders fed in different orders create the same batch.
+	var (
+		batches Transactions
+		ordered Transactions
+	)
+	for _, order := range [][]int{{0, 1, 2, 3, 4, 5}, {5, 2, 4, 0, 3, 1}, {3, 1, 5, 2, 0, 4}} {
+		b := NewBatchBuilder(signer, testRules, 5, 7, big.NewInt(1000), 1000000, 1024*1024)
+		for _, i := range order {
+			if err := b.Add(txs[i]); err != nil {
+				t.Fatalf("failed to add tx %d: %v", i, err)
+			}
+		}
+		ordered = b.Transactions()
+		var last *Transaction
+		for _, tx := range ordered {
+			if last != nil {
+				from, _ := Sender(signer, tx)
+				lastFrom, _ := Sender(signer, last)
+				if cmp := bytes.Compare(lastFrom[:], from[:]); cmp > 0 || cmp == 0 && last.Nonce() >= tx.Nonce() {
+					t.Errorf("order %v: txs not sorted by sender and nonce", order)
+				}
+			}
+			last = tx
+		}
+		batch, err := b.Build(testKey, AESTestScheme{}.DecryptionKey(testEonKey, 5))
+		if err != nil {
+			t.Fatalf("failed to build batch: %v", err)
+		}
+		batches = append(batches, batch)
+	}
+	for i, batch := range batches[1:] {
+		if batch.Hash() != batches[0].Hash() {
+			t.Errorf("order %d: wrong batch hash: have %v, want %v", i+1, batch.Hash(), batches[0].Hash())
+		}
+	}
+
+	batch := batches[0]
+	if from, err := Sender(signer, batch); err != nil || from != testAddr {
+		t.Errorf("wrong batch sender: have %v (%v), want %v", from, err, testAddr)
+	}
+	if batch.BatchIndex() != 5 || batch.L1BlockNumber() != 7 || batch.Timestamp().Int64() != 1000 || batch.ChainId().Cmp(testChainID) != 0 {
+		t.Errorf("wrong batch fields")
+	}
+	included, err := batch.DecodedTransactions(BatchPolicy{})
+	if err != nil {
+		t.Fatalf("failed to decode batch: %v", err)
+	}
+	if len(included) != len(ordered) {
+		t.Fatalf("wrong number of txs: have %d, want %d", len(included), len(ordered))
+	}
+	for i := range ordered {
+		if included[i].Hash() != ordered[i].Hash() {
+			t.Errorf("tx %d: batch order differs from Transactions", i)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrWrongBatchIndex = errors.New("transaction batch index does not match batch")
+
+// DecryptionStatus is the outcome of decoding and decrypting a transaction
+// contained in a batch.
+type DecryptionStatus uint8
+
+const (
+	// DecryptionStatusNone is the status of plaintext transactions,
+	// which don't need to be decrypted.
+	DecryptionStatusNone DecryptionStatus = iota
+	// DecryptionStatusDecrypted is the status of Shutter transactions
+	// whose payload was decrypted successfully.
+	DecryptionStatusDecrypted
+	// DecryptionStatusDecodeFailed is the status of transactions that
+	// could not be decoded or are not allowed in a batch.
+	DecryptionStatusDecodeFailed
+	// DecryptionStatusDecryptionFailed is the status of Shutter transactions
+	// whose payload could not be decrypted or decoded.
+	DecryptionStatusDecryptionFailed
+	// DecryptionStatusWrongBatchIndex is the status of Shutter transactions
+	// that were encrypted for another batch.
+	DecryptionStatusWrongBatchIndex
+)
+
+func (s DecryptionStatus) String() string {
+	switch s {
+	case DecryptionStatusNone:
+		return "none"
+	case DecryptionStatusDecrypted:
+		return "decrypted"
+	case DecryptionStatusDecodeFailed:
+		return "decode failed"
+	case DecryptionStatusDecryptionFailed:
+		return "decryption failed"
+	case DecryptionStatusWrongBatchIndex:
+		return "wrong batch index"
+	default:
+		return fmt.Sprintf("DecryptionStatus(%d)", uint8(s))
+	}
+}
+
+// DecryptedBatchTx is the result of decoding and decrypting a single
+// transaction of a batch.
+type DecryptedBatchTx struct {
+	Tx     *Transaction // nil if the transaction could not be decoded
+	Status DecryptionStatus
+	Err    error // reason for a failed status
+}
+
+// Executable returns whether the transaction can be executed.
+func (d *DecryptedBatchTx) Executable() bool {
+	return d.Status == DecryptionStatusNone || d.Status == DecryptionStatusDecrypted
+}
+
+// DecryptedBatch holds the decoded and decrypted transactions of a batch,
+// in the order they appear in the batch.
+type DecryptedBatch struct {
+	BatchIndex uint64
+	Txs        []*DecryptedBatchTx
+}
+
+// Transactions returns the executable transactions of the batch in order.
+func (b *DecryptedBatch) Transactions() Transactions {
+	txs := make(Transactions, 0, len(b.Txs))
+	for _, d := range b.Txs {
+		if d.Executable() {
+			txs = append(txs, d.Tx)
+		}
+	}
+	return txs
+}
+
+// BatchDecrypter decrypts the Shutter transactions of a batch after verifying
+// the decryption key of the batch.
+type BatchDecrypter interface {
+	Decrypter
+	DecryptionKeyVerifier
+}
+
+// DecryptBatch decodes every transaction contained in the batch transaction and
+// decrypts the Shutter transactions with the decryption key of the batch.
+// Plaintext transactions are left untouched. Failures of single transactions are
+// reported in their result and do not fail the whole batch.
+//
+// The decryption key is verified against the eon key first. A batch carrying an
+// invalid key is rejected with ErrInvalidDecryptionKey, as none of its Shutter
+// transactions could be decrypted.
+func DecryptBatch(batch *Transaction, eonKey EonKey, scheme BatchDecrypter) (*DecryptedBatch, error) {
+	if err := batch.VerifyDecryptionKey(eonKey, scheme); err != nil {
+		return nil, err
+	}
+	var (
+		index = batch.BatchIndex()
+		key   = batch.DecryptionKey()
+		raw   = batch.Transactions()
+	)
+	res := &DecryptedBatch{
+		BatchIndex: index,
+		Txs:        make([]*DecryptedBatchTx, len(raw)),
+	}
+	for i, b := range raw {
+		res.Txs[i] = decryptBatchTx(b, index, key, scheme)
+	}
+	return res, nil
+}
+
+func decryptBatchTx(b []byte, batchIndex uint64, key []byte, scheme Decrypter) *DecryptedBatchTx {
+	tx := new(Transaction)
+	if err := tx.UnmarshalBinary(b); err != nil {
+		return &DecryptedBatchTx{Status: DecryptionStatusDecodeFailed, Err: err}
+	}
+	switch tx.Type() {
+	case ShutterTxType:
+	case BatchTxType:
+		return &DecryptedBatchTx{Tx: tx, Status: DecryptionStatusDecodeFailed, Err: ErrInvalidTxType}
+	default:
+		return &DecryptedBatchTx{Tx: tx, Status: DecryptionStatusNone}
+	}
+	inner := tx.inner.(*ShutterTx)
+	if tx.BatchIndex() != batchIndex {
+		inner.DecryptionStatus = DecryptionStatusWrongBatchIndex
+		return &DecryptedBatchTx{Tx: tx, Status: DecryptionStatusWrongBatchIndex, Err: ErrWrongBatchIndex}
+	}
+	decrypted, err := tx.Decrypt(key, scheme)
+	if err != nil {
+		inner.DecryptionStatus = DecryptionStatusDecryptionFailed
+		return &DecryptedBatchTx{Tx: tx, Status: DecryptionStatusDecryptionFailed, Err: err}
+	}
+	return &DecryptedBatchTx{Tx: decrypted, Status: DecryptionStatusDecrypted}
+}
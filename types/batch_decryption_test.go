@@ -0,0 +1,137 @@
+package types
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// newTestBatchTx creates an unsigned batch transaction holding the given raw
+// transactions, carrying the AESTestScheme decryption key of the batch.
+func newTestBatchTx(batchIndex uint64, txs ...[]byte) *Transaction {
+	return NewTx(&BatchTx{
+		ChainID:       testChainID,
+		DecryptionKey: AESTestScheme{}.DecryptionKey(testEonKey, batchIndex),
+		BatchIndex:    batchIndex,
+		L1BlockNumber: 7,
+		Timestamp:     big.NewInt(1000),
+		Transactions:  txs,
+	})
+}
+
+func mustMarshalBinary(t *testing.T, tx *Transaction) []byte {
+	t.Helper()
+	b, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatalf("failed to encode tx: %v", err)
+	}
+	return b
+}
+
+func TestDecryptBatch(t *testing.T) {
+	plain := MustSignNewTx(testKey, NewShutterSigner(testChainID), &DynamicFeeTx{
+		ChainID:   testChainID,
+		Nonce:     0,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(10),
+		Gas:       21000,
+		To:        &testRecipient,
+		Value:     big.NewInt(1),
+	})
+	shutter := newTestShutterTx(t, testShutterPay, 5, 1)
+	otherBatch := newTestShutterTx(t, testShutterPay, 6, 2)
+	garbled := MustSignNewTx(testKey, NewShutterSigner(testChainID), &ShutterTx{
+		ChainID:          testChainID,
+		Nonce:            3,
+		GasTipCap:        big.NewInt(1),
+		GasFeeCap:        big.NewInt(10),
+		Gas:              100000,
+		EncryptedPayload: []byte("not encrypted with the batch key"),
+		BatchIndex:       5,
+	})
+	nested := newTestBatchTx(5)
+
+	batch := newTestBatchTx(5,
+		mustMarshalBinary(t, plain),
+		mustMarshalBinary(t, shutter),
+		mustMarshalBinary(t, otherBatch),
+		[]byte{0x02, 0xff},
+		mustMarshalBinary(t, garbled),
+		mustMarshalBinary(t, nested),
+	)
+	res, err := DecryptBatch(batch, testEonKey, AESTestScheme{})
+	if err != nil {
+		t.Fatalf("failed to decrypt batch: %v", err)
+	}
+	if res.BatchIndex != 5 {
+		t.Fatalf("wrong batch index: have %d, want 5", res.BatchIndex)
+	}
+	want := []struct {
+		status DecryptionStatus
+		err    error
+		tx     *Transaction
+	}{
+		{DecryptionStatusNone, nil, plain},
+		{DecryptionStatusDecrypted, nil, shutter},
+		{DecryptionStatusWrongBatchIndex, ErrWrongBatchIndex, otherBatch},
+		{DecryptionStatusDecodeFailed, nil, nil},
+		{DecryptionStatusDecryptionFailed, ErrDecryptionFailed, garbled},
+		{DecryptionStatusDecodeFailed, ErrInvalidTxType, nested},
+	}
+	if len(res.Txs) != len(want) {
+		t.Fatalf("wrong number of results: have %d, want %d", len(res.Txs), len(want))
+	}
+	for i, w := range want {
+		d := res.Txs[i]
+		if d.Status != w.status {
+			t.Errorf("tx %d: wrong status: have %v, want %v", i, d.Status, w.status)
+		}
+		if w.err != nil && !errors.Is(d.Err, w.err) {
+			t.Errorf("tx %d: wrong error: have %v, want %v", i, d.Err, w.err)
+		}
+		if d.Executable() != (w.err == nil && w.status != DecryptionStatusDecodeFailed) {
+			t.Errorf("tx %d: wrong executability %t", i, d.Executable())
+		}
+		switch {
+		case w.tx == nil && d.Tx != nil:
+			t.Errorf("tx %d: undecodable tx returned", i)
+		case w.tx != nil && (d.Tx == nil || d.Tx.Hash() != w.tx.Hash()):
+			t.Errorf("tx %d: wrong transaction returned", i)
+		}
+	}
+	if d := res.Txs[1].Tx; d.To() == nil || *d.To() != testRecipient || d.Value().Cmp(testShutterPay.Value) != 0 {
+		t.Errorf("shutter tx not decrypted: to %v, value %v", d.To(), d.Value())
+	}
+	if status := res.Txs[4].Tx.DecryptionStatus(); status != DecryptionStatusDecryptionFailed {
+		t.Errorf("wrong status on undecryptable tx: %v", status)
+	}
+	if txs := res.Transactions(); len(txs) != 2 || txs[0].Hash() != plain.Hash() || txs[1].Hash() != shutter.Hash() {
+		t.Errorf("wrong executable transactions: %v", txs)
+	}
+}
+
+func TestDecryptBatchNonBatchTx(t *testing.T) {
+	if _, err := DecryptBatch(newTestShutterTx(t, testShutterPay, 5, 0), testEonKey, AESTestScheme{}); !errors.Is(err, ErrInvalidTxType) {
+		t.Fatalf("wrong error: have %v, want %v", err, ErrInvalidTxType)
+	}
+}
+
+func TestDecryptBatchInvalidKey(t *testing.T) {
+	shutter := newTestShutterTx(t, testShutterPay, 5, 0)
+	for _, key := range [][]byte{
+		[]byte("garbage"),
+		AESTestScheme{}.DecryptionKey(testEonKey, 4),
+		AESTestScheme{}.DecryptionKey(EonKey("other eon key"), 5),
+	} {
+		batch := NewTx(&BatchTx{
+			ChainID:       testChainID,
+			DecryptionKey: key,
+			BatchIndex:    5,
+			Timestamp:     big.NewInt(1000),
+			Transactions:  [][]byte{mustMarshalBinary(t, shutter)},
+		})
+		if _, err := DecryptBatch(batch, testEonKey, AESTestScheme{}); !errors.Is(err, ErrInvalidDecryptionKey) {
+			t.Errorf("key %x: wrong error: have %v, want %v", key, err, ErrInvalidDecryptionKey)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package types
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var ErrBatchTxIndexOutOfRange = errors.New("transaction index out of range of batch")
+
+// Domain separation prefixes of the batch Merkle tree, so that leaves can't be
+// passed off as inner nodes.
+var (
+	batchMerkleLeafPrefix = []byte{0x00}
+	batchMerkleNodePrefix = []byte{0x01}
+)
+
+// BatchTransactionsRoot returns the root of the binary Merkle tree over the
+// transactions of a batch transaction. The leaves are the hashes of the encoded
+// transactions, padded with zero hashes to the next power of two. The root of a
+// batch without transactions is the zero hash. ErrInvalidTxType is returned for
+// transactions of other types.
+func (tx *Transaction) BatchTransactionsRoot() (common.Hash, error) {
+	if tx.Type() != BatchTxType {
+		return common.Hash{}, ErrInvalidTxType
+	}
+	level := batchMerkleLeaves(tx.Transactions())
+	if len(level) == 0 {
+		return common.Hash{}, nil
+	}
+	for len(level) > 1 {
+		level = batchMerkleParents(level)
+	}
+	return level[0], nil
+}
+
+// BatchInclusionProof returns the proof that the i'th transaction is included in
+// the batch, which can be checked against the root with VerifyBatchInclusion.
+func (tx *Transaction) BatchInclusionProof(i int) ([]common.Hash, error) {
+	if tx.Type() != BatchTxType {
+		return nil, ErrInvalidTxType
+	}
+	if i < 0 || i >= len(tx.Transactions()) {
+		return nil, ErrBatchTxIndexOutOfRange
+	}
+	var proof []common.Hash
+	level := batchMerkleLeaves(tx.Transactions())
+	for index := i; len(level) > 1; index /= 2 {
+		proof = append(proof, level[index^1])
+		level = batchMerkleParents(level)
+	}
+	return proof, nil
+}
+
+// VerifyBatchInclusion checks that txBytes is the encoding of the i'th
+// transaction of the batch with the given transactions root.
+func VerifyBatchInclusion(root common.Hash, i int, txBytes []byte, proof []common.Hash) bool {
+	if i < 0 {
+		return false
+	}
+	h := batchMerkleLeaf(txBytes)
+	index := i
+	for _, sibling := range proof {
+		if index%2 == 0 {
+			h = batchMerkleNode(h, sibling)
+		} else {
+			h = batchMerkleNode(sibling, h)
+		}
+		index /= 2
+	}
+	return index == 0 && h == root
+}
+
+// batchMerkleLeaves returns the leaves of the batch Merkle tree, padded to the
+// next power of two.
+func batchMerkleLeaves(txs [][]byte) []common.Hash {
+	if len(txs) == 0 {
+		return nil
+	}
+	width := 1
+	for width < len(txs) {
+		width *= 2
+	}
+	leaves := make([]common.Hash, width)
+	for i, b := range txs {
+		leaves[i] = batchMerkleLeaf(b)
+	}
+	return leaves
+}
+
+// batchMerkleParents returns the level of the batch Merkle tree above the given one.
+func batchMerkleParents(level []common.Hash) []common.Hash {
+	parents := make([]common.Hash, len(level)/2)
+	for i := range parents {
+		parents[i] = batchMerkleNode(level[2*i], level[2*i+1])
+	}
+	return parents
+}
+
+func batchMerkleLeaf(txBytes []byte) common.Hash {
+	return crypto.Keccak256Hash(batchMerkleLeafPrefix, txBytes)
+}
+
+func batchMerkleNode(left, right common.Hash) common.Hash {
+	return crypto.Keccak256Hash(batchMerkleNodePrefix, left[:], right[:])
+}
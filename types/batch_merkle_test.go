@@ -0,0 +1,104 @@
+package types
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// newMerkleTestBatch creates a batch transaction with n distinct raw transactions.
+func newMerkleTestBatch(n int) *Transaction {
+	txs := make([][]byte, n)
+	for i := range txs {
+		txs[i] = []byte(fmt.Sprintf("tx %d", i))
+	}
+	return newTestBatchTx(5, txs...)
+}
+
+func TestBatchTransactionsRoot(t *testing.T) {
+	leaf := func(i int) common.Hash { return batchMerkleLeaf([]byte(fmt.Sprintf("tx %d", i))) }
+	node := batchMerkleNode
+	tests := []struct {
+		n    int
+		root common.Hash
+	}{
+		{0, common.Hash{}},
+		{1, leaf(0)},
+		{2, node(leaf(0), leaf(1))},
+		// Odd counts are padded with zero hashes.
+		{3, node(node(leaf(0), leaf(1)), node(leaf(2), common.Hash{}))},
+		{5, node(
+			node(node(leaf(0), leaf(1)), node(leaf(2), leaf(3))),
+			node(node(leaf(4), common.Hash{}), node(common.Hash{}, common.Hash{})),
+		)},
+	}
+	for _, tt := range tests {
+		root, err := newMerkleTestBatch(tt.n).BatchTransactionsRoot()
+		if err != nil {
+			t.Fatalf("%d txs: failed to compute root: %v", tt.n, err)
+		}
+		if root != tt.root {
+			t.Errorf("%d txs: wrong root: have %v, want %v", tt.n, root, tt.root)
+		}
+	}
+
+	shutter := newTestShutterTx(t, testShutterPay, 5, 0)
+	if root, err := shutter.BatchTransactionsRoot(); err != ErrInvalidTxType || root != (common.Hash{}) {
+		t.Errorf("wrong result for non-batch tx: have %v (%v), want %v", root, err, ErrInvalidTxType)
+	}
+	if _, err := shutter.BatchInclusionProof(0); err != ErrInvalidTxType {
+		t.Errorf("wrong proof error for non-batch tx: have %v, want %v", err, ErrInvalidTxType)
+	}
+}
+
+func TestBatchInclusionProof(t *testing.T) {
+	for n := 1; n <= 9; n++ {
+		batch := newMerkleTestBatch(n)
+		root, err := batch.BatchTransactionsRoot()
+		if err != nil {
+			t.Fatalf("%d txs: failed to compute root: %v", n, err)
+		}
+		for i := 0; i < n; i++ {
+			proof, err := batch.BatchInclusionProof(i)
+			if err != nil {
+				t.Fatalf("%d txs: failed to prove tx %d: %v", n, i, err)
+			}
+			txBytes := batch.Transactions()[i]
+			if !VerifyBatchInclusion(root, i, txBytes, proof) {
+				t.Errorf("%d txs: invalid proof for tx %d", n, i)
+			}
+			// Tampered proofs are rejected.
+			if VerifyBatchInclusion(root, i, append([]byte{0x00}, txBytes...), proof) {
+				t.Errorf("%d txs: proof for tx %d accepted for other bytes", n, i)
+			}
+			if n > 1 && VerifyBatchInclusion(root, i^1, txBytes, proof) {
+				t.Errorf("%d txs: proof for tx %d accepted at index %d", n, i, i^1)
+			}
+			if VerifyBatchInclusion(root, i+1<<len(proof), txBytes, proof) {
+				t.Errorf("%d txs: proof for tx %d accepted beyond the tree", n, i)
+			}
+			if VerifyBatchInclusion(root, i, txBytes, append(proof, common.Hash{})) {
+				t.Errorf("%d txs: extended proof for tx %d accepted", n, i)
+			}
+			if len(proof) > 0 {
+				if VerifyBatchInclusion(root, i, txBytes, proof[:len(proof)-1]) {
+					t.Errorf("%d txs: truncated proof for tx %d accepted", n, i)
+				}
+				tampered := append([]common.Hash(nil), proof...)
+				tampered[0][0] ^= 0xff
+				if VerifyBatchInclusion(root, i, txBytes, tampered) {
+					t.Errorf("%d txs: tampered proof for tx %d accepted", n, i)
+				}
+			}
+			if VerifyBatchInclusion(root, -1, txBytes, proof) {
+				t.Errorf("%d txs: proof accepted for negative index", n)
+			}
+		}
+		for _, i := range []int{-1, n, n + 1} {
+			if _, err := batch.BatchInclusionProof(i); err != ErrBatchTxIndexOutOfRange {
+				t.Errorf("%d txs: wrong error for index %d: have %v, want %v", n, i, err, ErrBatchTxIndexOutOfRange)
+			}
+		}
+	}
+}
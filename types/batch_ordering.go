@@ -0,0 +1,207 @@
+package types
+
+import (
+	"container/heap"
+	"math"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// NewShutterTransactionsByPriceAndNonce creates a transaction set like
+// NewTransactionsByPriceAndNonce that only returns transactions which can be
+// included in the batch with the given index. It covers a single batch; to order
+// the transactions of several batches, use NewTransactionsByBatchPriceAndNonce.
+//
+// Batch transactions and Shutter transactions of passed batches are dropped, as
+// they can never be executed. The transactions of an account are cut off at its
+// first Shutter transaction of a future batch and at its first nonce gap, as
+// neither they nor the following transactions can be executed in nonce order.
+//
+// Note, the input map is reowned so the caller should not interact any more with
+// it after providing it to the constructor.
+func NewShutterTransactionsByPriceAndNonce(signer Signer, txs map[common.Address]Transactions, baseFee *big.Int, batchIndex uint64) *TransactionsByPriceAndNonce {
+	for from, accTxs := range txs {
+		if accTxs, _ = batchExecutable(accTxs, batchIndex, batchIndex); len(accTxs) == 0 {
+			delete(txs, from)
+		} else {
+			txs[from] = accTxs
+		}
+	}
+	return NewTransactionsByPriceAndNonce(signer, txs, baseFee)
+}
+
+// batchExecutable returns the nonce-sorted transactions of an account that can
+// be executed in the batches from first to last, along with the index of the
+// batch each of them is executed in. A Shutter transaction is executed in its
+// own batch, a plaintext transaction in the batch of the preceding transaction
+// of the account, or in the first batch.
+//
+// The first transaction other than a batch transaction determines the nonce
+// expected next, even if it is a stale Shutter transaction: its nonce is still
+// taken, so the account can't continue with the following nonce. Transactions
+// reusing a nonce that is already taken are skipped, so a stale Shutter
+// transaction sharing its nonce with a plaintext transaction doesn't hold back
+// the account.
+func batchExecutable(txs Transactions, first, last uint64) (Transactions, []uint64) {
+	var (
+		executable = make(Transactions, 0, len(txs))
+		batches    = make([]uint64, 0, len(txs))
+		batch      = first
+		next       uint64
+		started    bool
+	)
+	for _, tx := range txs {
+		if tx.Type() == BatchTxType {
+			continue
+		}
+		if !started {
+			next, started = tx.Nonce(), true
+		}
+		switch {
+		case tx.Type() == ShutterTxType && tx.BatchIndex() < batch:
+			continue
+		case tx.Type() == ShutterTxType && tx.BatchIndex() > last:
+			return executable, batches
+		case tx.Nonce() < next:
+			continue
+		case tx.Nonce() > next:
+			return executable, batches
+		}
+		if tx.Type() == ShutterTxType {
+			batch = tx.BatchIndex()
+		}
+		executable = append(executable, tx)
+		batches = append(batches, batch)
+		next++
+	}
+	return executable, batches
+}
+
+// txWithBatch wraps a transaction with its miner fee and the index of the batch
+// it is executed in.
+type txWithBatch struct {
+	*TxWithMinerFee
+	batch uint64
+}
+
+// txByBatchPriceAndTime implements the heap interface, ordering transactions by
+// batch index first and then by price and time like TxByPriceAndTime.
+type txByBatchPriceAndTime []*txWithBatch
+
+func (s txByBatchPriceAndTime) Len() int { return len(s) }
+func (s txByBatchPriceAndTime) Less(i, j int) bool {
+	if s[i].batch != s[j].batch {
+		return s[i].batch < s[j].batch
+	}
+	return TxByPriceAndTime{s[i].TxWithMinerFee, s[j].TxWithMinerFee}.Less(0, 1)
+}
+func (s txByBatchPriceAndTime) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+
+func (s *txByBatchPriceAndTime) Push(x interface{}) {
+	*s = append(*s, x.(*txWithBatch))
+}
+
+func (s *txByBatchPriceAndTime) Pop() interface{} {
+	old := *s
+	n := len(old)
+	x := old[n-1]
+	*s = old[0 : n-1]
+	return x
+}
+
+// TransactionsByBatchPriceAndNonce represents a set of transactions spanning
+// several batches that can return transactions grouped by the batch they are
+// executed in, in increasing batch order, and in a profit-maximizing sorted
+// order within each batch, while supporting removing entire batches of
+// transactions for non-executable accounts.
+type TransactionsByBatchPriceAndNonce struct {
+	txs     map[common.Address]Transactions // Per account nonce-sorted list of transactions
+	batches map[common.Address][]uint64     // Per account batch indices of the transactions
+	heads   txByBatchPriceAndTime           // Next transaction for each unique account (batch and price heap)
+	signer  Signer                          // Signer for the set of transactions
+	baseFee *big.Int                        // Current base fee
+}
+
+// NewTransactionsByBatchPriceAndNonce creates a transaction set that can
+// retrieve the transactions of the batch with the given index and the following
+// ones, grouped by batch and price sorted within each batch in a
+// nonce-honouring way.
+//
+// Shutter transactions are returned with the batch they are encrypted for, and
+// plaintext transactions with the batch of the preceding transaction of their
+// account, or with the given batch if there is none. Batch transactions and
+// Shutter transactions of passed batches are dropped, and the transactions of
+// an account are cut off at its first nonce gap, as in
+// NewShutterTransactionsByPriceAndNonce.
+//
+// Note, the input map is reowned so the caller should not interact any more with
+// it after providing it to the constructor.
+func NewTransactionsByBatchPriceAndNonce(signer Signer, txs map[common.Address]Transactions, baseFee *big.Int, batchIndex uint64) *TransactionsByBatchPriceAndNonce {
+	// Initialize a batch, price and received time based heap with the head
+	// transactions
+	batches := make(map[common.Address][]uint64, len(txs))
+	heads := make(txByBatchPriceAndTime, 0, len(txs))
+	for from, accTxs := range txs {
+		accTxs, accBatches := batchExecutable(accTxs, batchIndex, math.MaxUint64)
+		if len(accTxs) == 0 {
+			delete(txs, from)
+			continue
+		}
+		acc, _ := Sender(signer, accTxs[0])
+		wrapped, err := NewTxWithMinerFee(accTxs[0], baseFee)
+		// Remove transaction if sender doesn't match from, or if wrapping fails.
+		if acc != from || err != nil {
+			delete(txs, from)
+			continue
+		}
+		heads = append(heads, &txWithBatch{wrapped, accBatches[0]})
+		txs[from], batches[from] = accTxs[1:], accBatches[1:]
+	}
+	heap.Init(&heads)
+
+	// Assemble and return the transaction set
+	return &TransactionsByBatchPriceAndNonce{
+		txs:     txs,
+		batches: batches,
+		heads:   heads,
+		signer:  signer,
+		baseFee: baseFee,
+	}
+}
+
+// Peek returns the next transaction by batch and price.
+func (t *TransactionsByBatchPriceAndNonce) Peek() *Transaction {
+	if len(t.heads) == 0 {
+		return nil
+	}
+	return t.heads[0].tx
+}
+
+// BatchIndex returns the index of the batch the transaction returned by Peek is
+// executed in. It must not be called if Peek returns nil.
+func (t *TransactionsByBatchPriceAndNonce) BatchIndex() uint64 {
+	return t.heads[0].batch
+}
+
+// Shift replaces the current best head with the next one from the same account.
+func (t *TransactionsByBatchPriceAndNonce) Shift() {
+	acc, _ := Sender(t.signer, t.heads[0].tx)
+	if txs, ok := t.txs[acc]; ok && len(txs) > 0 {
+		if wrapped, err := NewTxWithMinerFee(txs[0], t.baseFee); err == nil {
+			batches := t.batches[acc]
+			t.heads[0] = &txWithBatch{wrapped, batches[0]}
+			t.txs[acc], t.batches[acc] = txs[1:], batches[1:]
+			heap.Fix(&t.heads, 0)
+			return
+		}
+	}
+	heap.Pop(&t.heads)
+}
+
+// Pop removes the best transaction, *not* replacing it with the next one from
+// the same account. This should be used when a transaction cannot be executed
+// and hence all subsequent ones should be discarded from the same account.
+func (t *TransactionsByBatchPriceAndNonce) Pop() {
+	heap.Pop(&t.heads)
+}
@@ -0,0 +1,166 @@
+package types
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestShutterTransactionsByPriceAndNonce(t *testing.T) {
+	signer := NewShutterSigner(testChainID)
+	keyB, _ := crypto.GenerateKey()
+	keyC, _ := crypto.GenerateKey()
+	keyD, _ := crypto.GenerateKey()
+
+	shutter := func(key *ecdsa.PrivateKey, nonce, batchIndex uint64, tip int64) *Transaction {
+		return MustSignNewTx(key, signer, &ShutterTx{ChainID: testChainID, Nonce: nonce, GasTipCap: big.NewInt(tip), GasFeeCap: big.NewInt(tip), Gas: 100000, EncryptedPayload: []byte{0x01}, BatchIndex: batchIndex})
+	}
+	legacy := func(key *ecdsa.PrivateKey, nonce uint64, price int64) *Transaction {
+		return MustSignNewTx(key, signer, &LegacyTx{Nonce: nonce, GasPrice: big.NewInt(price), Gas: 21000, To: &testRecipient, Value: big.NewInt(0)})
+	}
+	dynamic := func(key *ecdsa.PrivateKey, nonce uint64, tip int64) *Transaction {
+		return MustSignNewTx(key, signer, &DynamicFeeTx{ChainID: testChainID, Nonce: nonce, GasTipCap: big.NewInt(tip), GasFeeCap: big.NewInt(tip), Gas: 21000, To: &testRecipient, Value: big.NewInt(0)})
+	}
+	batch := MustSignNewTx(keyB, signer, &BatchTx{ChainID: testChainID, BatchIndex: 5, Timestamp: big.NewInt(0)})
+
+	var (
+		// Mixed types in nonce order, cut off at the Shutter transaction of
+		// a future batch.
+		a0, a1, a2 = dynamic(testKey, 0, 5), shutter(testKey, 1, 5, 5), legacy(testKey, 2, 5)
+		a3, a4     = shutter(testKey, 3, 6, 5), dynamic(testKey, 4, 5)
+		// A stale Shutter transaction sharing its nonce with a plaintext
+		// transaction, and a batch transaction.
+		b0Stale, b0, b1 = shutter(keyB, 0, 4, 9), legacy(keyB, 0, 3), shutter(keyB, 1, 5, 3)
+		// A stale Shutter transaction leaving a nonce gap.
+		c0Stale, c1 = shutter(keyC, 0, 4, 9), legacy(keyC, 1, 9)
+		// A batch transaction before the first nonce of the account.
+		dBatch, d5 = MustSignNewTx(keyD, signer, &BatchTx{ChainID: testChainID, BatchIndex: 5, Timestamp: big.NewInt(0)}), legacy(keyD, 5, 1)
+	)
+	txs := map[common.Address]Transactions{
+		testAddr:                               {a0, a1, a2, a3, a4},
+		crypto.PubkeyToAddress(keyB.PublicKey): {batch, b0Stale, b0, b1},
+		crypto.PubkeyToAddress(keyC.PublicKey): {c0Stale, c1},
+		crypto.PubkeyToAddress(keyD.PublicKey): {dBatch, d5},
+	}
+	set := NewShutterTransactionsByPriceAndNonce(signer, txs, nil, 5)
+
+	want := Transactions{a0, a1, a2, b0, b1, d5}
+	var have Transactions
+	for tx := set.Peek(); tx != nil; tx = set.Peek() {
+		have = append(have, tx)
+		set.Shift()
+	}
+	if len(have) != len(want) {
+		t.Fatalf("wrong number of transactions: have %d, want %d", len(have), len(want))
+	}
+	for i := range want {
+		if have[i].Hash() != want[i].Hash() {
+			t.Errorf("tx %d: have type %#x nonce %d, want type %#x nonce %d", i, have[i].Type(), have[i].Nonce(), want[i].Type(), want[i].Nonce())
+		}
+	}
+}
+
+func TestShutterTransactionsByPriceAndNonceGrouping(t *testing.T) {
+	signer := NewShutterSigner(testChainID)
+	var txs Transactions
+	for nonce := uint64(0); nonce < 6; nonce++ {
+		// Two transactions for each of the batches 3, 4 and 5.
+		txs = append(txs, MustSignNewTx(testKey, signer, &ShutterTx{ChainID: testChainID, Nonce: nonce, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(1), Gas: 100000, EncryptedPayload: []byte{0x01}, BatchIndex: 3 + nonce/2}))
+	}
+	// Only the transactions of the given batch are returned. Later batches
+	// can't be executed before the transactions of their predecessors.
+	for batchIndex, want := range map[uint64][]uint64{3: {0, 1}, 4: nil, 5: nil} {
+		set := NewShutterTransactionsByPriceAndNonce(signer, map[common.Address]Transactions{testAddr: txs}, nil, batchIndex)
+		var have []uint64
+		for tx := set.Peek(); tx != nil; tx = set.Peek() {
+			if tx.BatchIndex() != batchIndex {
+				t.Errorf("batch %d: tx of batch %d returned", batchIndex, tx.BatchIndex())
+			}
+			have = append(have, tx.Nonce())
+			set.Shift()
+		}
+		if fmt.Sprint(have) != fmt.Sprint(want) {
+			t.Errorf("batch %d: wrong nonces: have %v, want %v", batchIndex, have, want)
+		}
+	}
+	// Once the earlier batches are included, the transactions of the next
+	// batch follow.
+	set := NewShutterTransactionsByPriceAndNonce(signer, map[common.Address]Transactions{testAddr: txs[2:]}, nil, 4)
+	for _, nonce := range []uint64{2, 3} {
+		if tx := set.Peek(); tx == nil || tx.Nonce() != nonce {
+			t.Fatalf("batch 4: wrong tx, want nonce %d", nonce)
+		}
+		set.Shift()
+	}
+	if tx := set.Peek(); tx != nil {
+		t.Fatalf("batch 4: unexpected tx with nonce %d", tx.Nonce())
+	}
+}
+
+func TestTransactionsByBatchPriceAndNonce(t *testing.T) {
+	signer := NewShutterSigner(testChainID)
+	keyB, _ := crypto.GenerateKey()
+	keyC, _ := crypto.GenerateKey()
+	keyD, _ := crypto.GenerateKey()
+
+	shutter := func(key *ecdsa.PrivateKey, nonce, batchIndex uint64, tip int64) *Transaction {
+		return MustSignNewTx(key, signer, &ShutterTx{ChainID: testChainID, Nonce: nonce, GasTipCap: big.NewInt(tip), GasFeeCap: big.NewInt(tip), Gas: 100000, EncryptedPayload: []byte{0x01}, BatchIndex: batchIndex})
+	}
+	legacy := func(key *ecdsa.PrivateKey, nonce uint64, price int64) *Transaction {
+		return MustSignNewTx(key, signer, &LegacyTx{Nonce: nonce, GasPrice: big.NewInt(price), Gas: 21000, To: &testRecipient, Value: big.NewInt(0)})
+	}
+
+	var (
+		// A cheap account spanning the batches 5 and 7, whose plaintext
+		// transactions follow the batch of the preceding Shutter transaction.
+		a0, a1, a2, a3 = shutter(testKey, 0, 5, 1), legacy(testKey, 1, 1), shutter(testKey, 2, 7, 9), legacy(testKey, 3, 9)
+		// An expensive account cut off at a Shutter transaction of a batch
+		// passed by its predecessor.
+		b0, b1, b2, b3 = legacy(keyB, 0, 5), shutter(keyB, 1, 6, 5), shutter(keyB, 2, 5, 9), legacy(keyB, 3, 9)
+		// A stale Shutter transaction sharing its nonce with a plaintext one.
+		c0Stale, c0 = shutter(keyC, 0, 4, 9), legacy(keyC, 0, 3)
+		// A batch transaction before the first nonce of the account.
+		dBatch, d5 = MustSignNewTx(keyD, signer, &BatchTx{ChainID: testChainID, BatchIndex: 5, Timestamp: big.NewInt(0)}), legacy(keyD, 5, 2)
+	)
+	txs := map[common.Address]Transactions{
+		testAddr:                               {a0, a1, a2, a3},
+		crypto.PubkeyToAddress(keyB.PublicKey): {b0, b1, b2, b3},
+		crypto.PubkeyToAddress(keyC.PublicKey): {c0Stale, c0},
+		crypto.PubkeyToAddress(keyD.PublicKey): {dBatch, d5},
+	}
+	set := NewTransactionsByBatchPriceAndNonce(signer, txs, nil, 5)
+
+	want := []struct {
+		tx    *Transaction
+		batch uint64
+	}{
+		{b0, 5}, {c0, 5}, {d5, 5}, {a0, 5}, {a1, 5},
+		{b1, 6},
+		{a2, 7}, {a3, 7},
+	}
+	for i, w := range want {
+		tx := set.Peek()
+		if tx == nil {
+			t.Fatalf("tx %d: set exhausted, want type %#x nonce %d", i, w.tx.Type(), w.tx.Nonce())
+		}
+		if tx.Hash() != w.tx.Hash() || set.BatchIndex() != w.batch {
+			t.Errorf("tx %d: have type %#x nonce %d in batch %d, want type %#x nonce %d in batch %d", i, tx.Type(), tx.Nonce(), set.BatchIndex(), w.tx.Type(), w.tx.Nonce(), w.batch)
+		}
+		set.Shift()
+	}
+	if tx := set.Peek(); tx != nil {
+		t.Errorf("unexpected tx: type %#x nonce %d", tx.Type(), tx.Nonce())
+	}
+
+	// Popping a transaction drops the following ones of its account, even in
+	// later batches.
+	set = NewTransactionsByBatchPriceAndNonce(signer, map[common.Address]Transactions{testAddr: {a0, a1, a2, a3}}, nil, 5)
+	set.Pop()
+	if tx := set.Peek(); tx != nil {
+		t.Errorf("unexpected tx after pop: type %#x nonce %d", tx.Type(), tx.Nonce())
+	}
+}
@@ -0,0 +1,101 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	ErrBatchTooManyTxs = errors.New("too many transactions in batch")
+	ErrBatchTxTooLarge = errors.New("transaction in batch too large")
+)
+
+// BatchPolicy restricts the transactions that are allowed in a batch.
+type BatchPolicy struct {
+	MaxTransactions int                // Maximum number of transactions, 0 means unlimited
+	MaxTxSize       common.StorageSize // Maximum encoded size of a single transaction, 0 means unlimited
+	AllowBatchTx    bool               // Whether nested batch transactions are allowed
+	ShutterTxOnly   bool               // Whether only Shutter transactions are allowed
+}
+
+// DefaultBatchPolicy rejects nested batch transactions and limits the number
+// and size of the transactions in a batch.
+var DefaultBatchPolicy = BatchPolicy{
+	MaxTransactions: 1024,
+	MaxTxSize:       128 * 1024,
+}
+
+// BatchTxError is returned when a transaction contained in a batch is invalid.
+type BatchTxError struct {
+	Index int // Position of the transaction in the batch
+	Err   error
+}
+
+func (e *BatchTxError) Error() string {
+	return fmt.Sprintf("invalid transaction %d in batch: %v", e.Index, e.Err)
+}
+
+func (e *BatchTxError) Unwrap() error {
+	return e.Err
+}
+
+// decodedBatch is the cached result of decoding the transactions of a batch.
+type decodedBatch struct {
+	txs Transactions
+	err error
+}
+
+// DecodedTransactions decodes the transactions contained in a batch transaction
+// and checks them against the policy. The decoded transactions are cached, so
+// repeated calls only apply the policy.
+func (tx *Transaction) DecodedTransactions(policy BatchPolicy) (Transactions, error) {
+	if tx.Type() != BatchTxType {
+		return nil, ErrInvalidTxType
+	}
+	raw := tx.Transactions()
+	if policy.MaxTransactions > 0 && len(raw) > policy.MaxTransactions {
+		return nil, ErrBatchTooManyTxs
+	}
+	if policy.MaxTxSize > 0 {
+		for i, b := range raw {
+			if common.StorageSize(len(b)) > policy.MaxTxSize {
+				return nil, &BatchTxError{Index: i, Err: ErrBatchTxTooLarge}
+			}
+		}
+	}
+	txs, err := tx.decodeTransactions()
+	if err != nil {
+		return nil, err
+	}
+	for i, btx := range txs {
+		switch {
+		case btx.Type() == BatchTxType && !policy.AllowBatchTx:
+			return nil, &BatchTxError{Index: i, Err: ErrInvalidTxType}
+		case btx.Type() != ShutterTxType && policy.ShutterTxOnly:
+			return nil, &BatchTxError{Index: i, Err: ErrInvalidTxType}
+		}
+	}
+	return append(Transactions(nil), txs...), nil
+}
+
+// decodeTransactions decodes the transactions of a batch transaction, or
+// returns the cached result of a previous call.
+func (tx *Transaction) decodeTransactions() (Transactions, error) {
+	if batch := tx.batch.Load(); batch != nil {
+		return batch.(decodedBatch).txs, batch.(decodedBatch).err
+	}
+	raw := tx.Transactions()
+	batch := decodedBatch{txs: make(Transactions, len(raw))}
+	for i, b := range raw {
+		btx := new(Transaction)
+		if err := btx.UnmarshalBinary(b); err != nil {
+			batch = decodedBatch{err: &BatchTxError{Index: i, Err: err}}
+			break
+		}
+		batch.txs[i] = btx
+	}
+	tx.batch.Store(batch)
+	return batch.txs, batch.err
+}
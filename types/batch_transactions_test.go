@@ -0,0 +1,94 @@
+package types
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestDecodedTransactionsPolicy(t *testing.T) {
+	shutter := mustMarshalBinary(t, newTestShutterTx(t, testShutterPay, 5, 0))
+	plain := mustMarshalBinary(t, MustSignNewTx(testKey, NewShutterSigner(testChainID), &DynamicFeeTx{ChainID: testChainID, Nonce: 1, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(1), Gas: 21000, To: &testRecipient, Value: big.NewInt(0)}))
+	nested := mustMarshalBinary(t, newTestBatchTx(4))
+
+	tests := []struct {
+		txs    [][]byte
+		policy BatchPolicy
+		err    error
+		index  int // index of the BatchTxError, -1 if none
+	}{
+		{txs: [][]byte{shutter, plain}, policy: DefaultBatchPolicy, index: -1},
+		{txs: [][]byte{shutter, plain}, policy: BatchPolicy{MaxTransactions: 2}, index: -1},
+		{txs: [][]byte{shutter, plain, shutter}, policy: BatchPolicy{MaxTransactions: 2}, err: ErrBatchTooManyTxs, index: -1},
+		{txs: [][]byte{plain, shutter}, policy: BatchPolicy{MaxTxSize: 120}, err: ErrBatchTxTooLarge, index: 1},
+		{txs: [][]byte{shutter, nested}, policy: DefaultBatchPolicy, err: ErrInvalidTxType, index: 1},
+		{txs: [][]byte{shutter, nested}, policy: BatchPolicy{AllowBatchTx: true}, index: -1},
+		{txs: [][]byte{shutter, plain}, policy: BatchPolicy{ShutterTxOnly: true}, err: ErrInvalidTxType, index: 1},
+	}
+	if len(plain) > 120 || len(shutter) <= 120 {
+		t.Fatalf("unexpected test tx sizes: %d, %d", len(plain), len(shutter))
+	}
+	for i, tt := range tests {
+		txs, err := newTestBatchTx(5, tt.txs...).DecodedTransactions(tt.policy)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("test %d: wrong error: have %v, want %v", i, err, tt.err)
+			continue
+		}
+		index := -1
+		var txErr *BatchTxError
+		if errors.As(err, &txErr) {
+			index = txErr.Index
+		}
+		if index != tt.index {
+			t.Errorf("test %d: wrong error index: have %d, want %d", i, index, tt.index)
+		}
+		if err == nil && len(txs) != len(tt.txs) {
+			t.Errorf("test %d: wrong number of txs: have %d, want %d", i, len(txs), len(tt.txs))
+		}
+	}
+}
+
+func TestDecodedTransactionsInvalidEntry(t *testing.T) {
+	shutter := mustMarshalBinary(t, newTestShutterTx(t, testShutterPay, 5, 0))
+	batch := newTestBatchTx(5, shutter, shutter, []byte{ShutterTxType, 0xc0})
+
+	_, err := batch.DecodedTransactions(DefaultBatchPolicy)
+	var txErr *BatchTxError
+	if !errors.As(err, &txErr) || txErr.Index != 2 {
+		t.Fatalf("wrong error: have %v, want BatchTxError for index 2", err)
+	}
+	// The decoding error is cached as well.
+	if _, err2 := batch.DecodedTransactions(BatchPolicy{}); err2 != err {
+		t.Errorf("wrong cached error: have %v, want %v", err2, err)
+	}
+	if _, err := newTestShutterTx(t, testShutterPay, 5, 0).DecodedTransactions(DefaultBatchPolicy); err != ErrInvalidTxType {
+		t.Errorf("wrong error for non-batch tx: have %v, want %v", err, ErrInvalidTxType)
+	}
+}
+
+func TestDecodedTransactionsCache(t *testing.T) {
+	shutter := newTestShutterTx(t, testShutterPay, 5, 0)
+	batch := newTestBatchTx(5, mustMarshalBinary(t, shutter), mustMarshalBinary(t, newTestBatchTx(4)))
+
+	first, err := batch.DecodedTransactions(BatchPolicy{AllowBatchTx: true})
+	if err != nil {
+		t.Fatalf("failed to decode batch: %v", err)
+	}
+	if first[0].Hash() != shutter.Hash() {
+		t.Fatalf("wrong decoded tx: have %v, want %v", first[0].Hash(), shutter.Hash())
+	}
+	// Modifying the returned slice doesn't affect the cache.
+	first[0] = nil
+
+	second, err := batch.DecodedTransactions(BatchPolicy{AllowBatchTx: true})
+	if err != nil {
+		t.Fatalf("failed to decode batch again: %v", err)
+	}
+	if second[0] == nil || second[1] != first[1] {
+		t.Errorf("second call did not return the cached transactions")
+	}
+	// The policy is still applied to the cached transactions.
+	if _, err := batch.DecodedTransactions(DefaultBatchPolicy); !errors.Is(err, ErrInvalidTxType) {
+		t.Errorf("wrong error with cached transactions: have %v, want %v", err, ErrInvalidTxType)
+	}
+}
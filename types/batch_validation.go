@@ -0,0 +1,111 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var (
+	ErrBatchIndexNotSuccessive = errors.New("batch index does not follow previous batch")
+	ErrL1BlockNumberDecreased  = errors.New("l1 block number lower than in previous batch")
+	ErrTimestampDecreased      = errors.New("timestamp lower than in previous batch")
+	ErrTimestampOutOfBounds    = errors.New("timestamp out of bounds of l1 block")
+	ErrBatchChainIDMismatch    = errors.New("chain id differs from previous batch")
+)
+
+// BatchSuccessionError is returned when a batch is not a valid successor of the
+// previous batch.
+type BatchSuccessionError struct {
+	Prev, Next uint64 // Batch indices of the previous and the next batch
+	Err        error
+
+	// Reorg is set if the batches are not on the same chain, e.g. because the
+	// L1 chain was reorganized, rather than next being invalid.
+	Reorg bool
+}
+
+func (e *BatchSuccessionError) Error() string {
+	return fmt.Sprintf("batch %d is no successor of batch %d: %v", e.Next, e.Prev, e.Err)
+}
+
+func (e *BatchSuccessionError) Unwrap() error {
+	return e.Err
+}
+
+// BatchSuccessionConfig holds the bounds used to validate consecutive batches.
+type BatchSuccessionConfig struct {
+	// L1BlockTime returns the timestamp of the L1 block with the given number.
+	// If it is nil, batch timestamps are not checked against the L1 block.
+	L1BlockTime func(number uint64) (uint64, error)
+
+	// MaxTimestampDrift is the maximum number of seconds the timestamp of a
+	// batch may be ahead of the timestamp of its L1 block.
+	MaxTimestampDrift uint64
+}
+
+// DefaultBatchSuccessionConfig does not check batch timestamps against the L1 chain.
+var DefaultBatchSuccessionConfig = &BatchSuccessionConfig{}
+
+// ValidateBatchSuccession checks that next is a valid successor of the batch
+// prev using DefaultBatchSuccessionConfig.
+func ValidateBatchSuccession(prev, next *Transaction) error {
+	return DefaultBatchSuccessionConfig.ValidateBatchSuccession(prev, next)
+}
+
+// ValidateBatchSuccession checks that next is a valid successor of the batch
+// prev: the batch index increases by one, the L1 block number and the timestamp
+// don't decrease, the timestamp is within the bounds of the L1 block, the chain
+// IDs match and all contained Shutter transactions belong to the batch.
+func (c *BatchSuccessionConfig) ValidateBatchSuccession(prev, next *Transaction) error {
+	if prev.Type() != BatchTxType || next.Type() != BatchTxType {
+		return ErrInvalidTxType
+	}
+	fail := func(err error, reorg bool) error {
+		return &BatchSuccessionError{Prev: prev.BatchIndex(), Next: next.BatchIndex(), Err: err, Reorg: reorg}
+	}
+	if next.ChainId().Cmp(prev.ChainId()) != 0 {
+		return fail(ErrBatchChainIDMismatch, false)
+	}
+	if next.BatchIndex() != prev.BatchIndex()+1 {
+		// A batch that doesn't advance the index belongs to another chain.
+		return fail(ErrBatchIndexNotSuccessive, next.BatchIndex() <= prev.BatchIndex())
+	}
+	if next.L1BlockNumber() < prev.L1BlockNumber() {
+		return fail(ErrL1BlockNumberDecreased, true)
+	}
+	timestamp := batchTimestamp(next)
+	if timestamp.Cmp(batchTimestamp(prev)) < 0 {
+		return fail(ErrTimestampDecreased, false)
+	}
+	if c.L1BlockTime != nil {
+		l1Time, err := c.L1BlockTime(next.L1BlockNumber())
+		if err != nil {
+			return err
+		}
+		lower := new(big.Int).SetUint64(l1Time)
+		upper := new(big.Int).Add(lower, new(big.Int).SetUint64(c.MaxTimestampDrift))
+		if timestamp.Cmp(lower) < 0 || timestamp.Cmp(upper) > 0 {
+			return fail(ErrTimestampOutOfBounds, false)
+		}
+	}
+	txs, err := next.decodeTransactions()
+	if err != nil {
+		return fail(err, false)
+	}
+	for i, tx := range txs {
+		if tx.Type() == ShutterTxType && tx.BatchIndex() != next.BatchIndex() {
+			return fail(&BatchTxError{Index: i, Err: ErrWrongBatchIndex}, false)
+		}
+	}
+	return nil
+}
+
+// batchTimestamp returns the timestamp of a batch transaction, treating a
+// missing timestamp as zero.
+func batchTimestamp(tx *Transaction) *big.Int {
+	if ts := tx.Timestamp(); ts != nil {
+		return ts
+	}
+	return new(big.Int)
+}
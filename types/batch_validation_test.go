@@ -0,0 +1,93 @@
+package types
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// newSuccessionTestBatch creates a batch transaction with the given fields.
+func newSuccessionTestBatch(chainID int64, batchIndex, l1BlockNumber, timestamp uint64, txs ...[]byte) *Transaction {
+	return NewTx(&BatchTx{
+		ChainID:       big.NewInt(chainID),
+		BatchIndex:    batchIndex,
+		L1BlockNumber: l1BlockNumber,
+		Timestamp:     new(big.Int).SetUint64(timestamp),
+		Transactions:  txs,
+	})
+}
+
+// errTestAnyError matches any error wrapped in a BatchSuccessionError.
+var errTestAnyError = errors.New("any error")
+
+func TestValidateBatchSuccession(t *testing.T) {
+	shutter := func(batchIndex uint64) []byte {
+		return mustMarshalBinary(t, newTestShutterTx(t, testShutterPay, batchIndex, 0))
+	}
+	config := &BatchSuccessionConfig{
+		L1BlockTime: func(number uint64) (uint64, error) {
+			return 1000 + 12*number, nil
+		},
+		MaxTimestampDrift: 30,
+	}
+	prev := newSuccessionTestBatch(1, 5, 10, 1125)
+
+	tests := []struct {
+		next  *Transaction
+		err   error
+		reorg bool
+	}{
+		{next: newSuccessionTestBatch(1, 6, 10, 1125, shutter(6))},
+		{next: newSuccessionTestBatch(1, 6, 11, 1132)},
+		{next: newSuccessionTestBatch(1, 6, 11, 1162)},
+		{next: newSuccessionTestBatch(2, 6, 10, 1125), err: ErrBatchChainIDMismatch},
+		{next: newSuccessionTestBatch(1, 7, 10, 1125), err: ErrBatchIndexNotSuccessive},
+		{next: newSuccessionTestBatch(1, 5, 10, 1125), err: ErrBatchIndexNotSuccessive, reorg: true},
+		{next: newSuccessionTestBatch(1, 4, 10, 1125), err: ErrBatchIndexNotSuccessive, reorg: true},
+		{next: newSuccessionTestBatch(1, 6, 9, 1125), err: ErrL1BlockNumberDecreased, reorg: true},
+		{next: newSuccessionTestBatch(1, 6, 10, 1124), err: ErrTimestampDecreased},
+		{next: newSuccessionTestBatch(1, 6, 11, 1131), err: ErrTimestampOutOfBounds},
+		{next: newSuccessionTestBatch(1, 6, 11, 1163), err: ErrTimestampOutOfBounds},
+		{next: newSuccessionTestBatch(1, 6, 10, 1125, shutter(6), shutter(5)), err: ErrWrongBatchIndex},
+		{next: newSuccessionTestBatch(1, 6, 10, 1125, []byte{ShutterTxType}), err: errTestAnyError},
+	}
+	for i, tt := range tests {
+		err := config.ValidateBatchSuccession(prev, tt.next)
+		if tt.err == nil {
+			if err != nil {
+				t.Errorf("test %d: unexpected error: %v", i, err)
+			}
+			continue
+		}
+		var succErr *BatchSuccessionError
+		if !errors.As(err, &succErr) {
+			t.Errorf("test %d: wrong error: have %v, want BatchSuccessionError", i, err)
+			continue
+		}
+		if tt.err != errTestAnyError && !errors.Is(err, tt.err) {
+			t.Errorf("test %d: wrong error: have %v, want %v", i, err, tt.err)
+		}
+		if succErr.Prev != prev.BatchIndex() || succErr.Next != tt.next.BatchIndex() {
+			t.Errorf("test %d: wrong batch indices: have %d -> %d", i, succErr.Prev, succErr.Next)
+		}
+		if succErr.Reorg != tt.reorg {
+			t.Errorf("test %d: wrong reorg classification: have %t, want %t", i, succErr.Reorg, tt.reorg)
+		}
+	}
+}
+
+func TestValidateBatchSuccessionDefaults(t *testing.T) {
+	prev := newSuccessionTestBatch(1, 5, 10, 1125)
+	// Timestamps are not checked against the L1 block by default.
+	if err := ValidateBatchSuccession(prev, newSuccessionTestBatch(1, 6, 10, 1e9)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := ValidateBatchSuccession(prev, newTestShutterTx(t, testShutterPay, 6, 0)); err != ErrInvalidTxType {
+		t.Errorf("wrong error for non-batch tx: have %v, want %v", err, ErrInvalidTxType)
+	}
+	l1Err := errors.New("unknown l1 block")
+	config := &BatchSuccessionConfig{L1BlockTime: func(uint64) (uint64, error) { return 0, l1Err }}
+	if err := config.ValidateBatchSuccession(prev, newSuccessionTestBatch(1, 6, 10, 1125)); err != l1Err {
+		t.Errorf("wrong error of L1BlockTime: have %v, want %v", err, l1Err)
+	}
+}
@@ -85,22 +85,31 @@ type Header struct {
 
 	// BaseFee was added by EIP-1559 and is ignored in legacy headers.
 	BaseFee *big.Int `json:"baseFeePerGas" rlp:"optional"`
+
+	// BatchIndex, L1BlockNumber and DecryptionKeyHash commit to the batch
+	// executed in a Shutter block. They are nil in all other headers.
+	BatchIndex        *uint64      `json:"batchIndex"        rlp:"optional"`
+	L1BlockNumber     *uint64      `json:"l1BlockNumber"     rlp:"optional"`
+	DecryptionKeyHash *common.Hash `json:"decryptionKeyHash" rlp:"optional"`
 }
 
 // field type overrides for gencodec
 type headerMarshaling struct {
-	Difficulty *hexutil.Big
-	Number     *hexutil.Big
-	GasLimit   hexutil.Uint64
-	GasUsed    hexutil.Uint64
-	Time       hexutil.Uint64
-	Extra      hexutil.Bytes
-	BaseFee    *hexutil.Big
-	Hash       common.Hash `json:"hash"` // adds call to Hash() in MarshalJSON
+	Difficulty    *hexutil.Big
+	Number        *hexutil.Big
+	GasLimit      hexutil.Uint64
+	GasUsed       hexutil.Uint64
+	Time          hexutil.Uint64
+	Extra         hexutil.Bytes
+	BaseFee       *hexutil.Big
+	BatchIndex    *hexutil.Uint64
+	L1BlockNumber *hexutil.Uint64
+	Hash          common.Hash `json:"hash"` // adds call to Hash() in MarshalJSON
 }
 
 // Hash returns the block hash of the header, which is simply the keccak256 hash of its
-// RLP encoding.
+// RLP encoding. The optional fields are only encoded if set, so headers without
+// them hash as before.
 func (h *Header) Hash() common.Hash {
 	return rlpHash(h)
 }
@@ -134,9 +143,29 @@ func (h *Header) SanityCheck() error {
 			return fmt.Errorf("too large base fee: bitlen %d", bfLen)
 		}
 	}
+	if h.IsShutter() {
+		if !h.hasShutterFields() {
+			return fmt.Errorf("incomplete shutter header fields")
+		}
+		if h.BaseFee == nil {
+			return fmt.Errorf("shutter header fields without base fee")
+		}
+	}
 	return nil
 }
 
+// IsShutter returns whether the header contains any of the fields committing to
+// a Shutter batch. Headers passing SanityCheck contain either all or none of them.
+func (h *Header) IsShutter() bool {
+	return h.BatchIndex != nil || h.L1BlockNumber != nil || h.DecryptionKeyHash != nil
+}
+
+// hasShutterFields returns whether the header contains all fields committing to
+// a Shutter batch.
+func (h *Header) hasShutterFields() bool {
+	return h.BatchIndex != nil && h.L1BlockNumber != nil && h.DecryptionKeyHash != nil
+}
+
 // EmptyBody returns true if there is no additional 'body' to complete the header
 // that is: no transactions and no uncles.
 func (h *Header) EmptyBody() bool {
@@ -188,7 +217,7 @@ type extblock struct {
 //
 // The values of TxHash, UncleHash, ReceiptHash and Bloom in header
 // are ignored and set to values derived from the given txs, uncles
-// and receipts.
+// and receipts. The hasher is typically created with NewStackTrie.
 func NewBlock(header *Header, txs []*Transaction, uncles []*Header, receipts []*Receipt, hasher TrieHasher) *Block {
 	b := &Block{header: CopyHeader(header), td: new(big.Int)}
 
@@ -241,6 +270,18 @@ func CopyHeader(h *Header) *Header {
 	if h.BaseFee != nil {
 		cpy.BaseFee = new(big.Int).Set(h.BaseFee)
 	}
+	if h.BatchIndex != nil {
+		batchIndex := *h.BatchIndex
+		cpy.BatchIndex = &batchIndex
+	}
+	if h.L1BlockNumber != nil {
+		l1BlockNumber := *h.L1BlockNumber
+		cpy.L1BlockNumber = &l1BlockNumber
+	}
+	if h.DecryptionKeyHash != nil {
+		decryptionKeyHash := *h.DecryptionKeyHash
+		cpy.DecryptionKeyHash = &decryptionKeyHash
+	}
 	if len(h.Extra) > 0 {
 		cpy.Extra = make([]byte, len(h.Extra))
 		copy(cpy.Extra, h.Extra)
@@ -308,6 +349,30 @@ func (b *Block) BaseFee() *big.Int {
 	return new(big.Int).Set(b.header.BaseFee)
 }
 
+func (b *Block) BatchIndex() *uint64 {
+	if b.header.BatchIndex == nil {
+		return nil
+	}
+	batchIndex := *b.header.BatchIndex
+	return &batchIndex
+}
+
+func (b *Block) L1BlockNumber() *uint64 {
+	if b.header.L1BlockNumber == nil {
+		return nil
+	}
+	l1BlockNumber := *b.header.L1BlockNumber
+	return &l1BlockNumber
+}
+
+func (b *Block) DecryptionKeyHash() *common.Hash {
+	if b.header.DecryptionKeyHash == nil {
+		return nil
+	}
+	decryptionKeyHash := *b.header.DecryptionKeyHash
+	return &decryptionKeyHash
+}
+
 func (b *Block) Header() *Header { return CopyHeader(b.header) }
 
 // Body returns the non-header content of the block.
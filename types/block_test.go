@@ -0,0 +1,146 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// newTestHeader returns a header with the given base fee and without Shutter
+// fields.
+func newTestHeader(baseFee *big.Int) *Header {
+	return &Header{
+		ParentHash:  common.HexToHash("0x01"),
+		UncleHash:   EmptyUncleHash,
+		Coinbase:    testRecipient,
+		Root:        common.HexToHash("0x02"),
+		TxHash:      EmptyRootHash,
+		ReceiptHash: EmptyRootHash,
+		Difficulty:  big.NewInt(131072),
+		Number:      big.NewInt(100),
+		GasLimit:    8000000,
+		GasUsed:     21000,
+		Time:        1600000000,
+		Extra:       []byte("extra"),
+		MixDigest:   common.HexToHash("0x03"),
+		Nonce:       EncodeNonce(42),
+		BaseFee:     baseFee,
+	}
+}
+
+// withShutterFields returns a copy of the header with the Shutter fields set.
+func withShutterFields(h *Header) *Header {
+	cpy := CopyHeader(h)
+	batchIndex, l1BlockNumber, keyHash := uint64(5), uint64(7), common.HexToHash("0x04")
+	cpy.BatchIndex, cpy.L1BlockNumber, cpy.DecryptionKeyHash = &batchIndex, &l1BlockNumber, &keyHash
+	return cpy
+}
+
+func TestHeaderHashUnchanged(t *testing.T) {
+	for _, baseFee := range []*big.Int{nil, big.NewInt(1000000000)} {
+		h := newTestHeader(baseFee)
+		upstream := &gethtypes.Header{
+			ParentHash:  h.ParentHash,
+			UncleHash:   h.UncleHash,
+			Coinbase:    h.Coinbase,
+			Root:        h.Root,
+			TxHash:      h.TxHash,
+			ReceiptHash: h.ReceiptHash,
+			Difficulty:  h.Difficulty,
+			Number:      h.Number,
+			GasLimit:    h.GasLimit,
+			GasUsed:     h.GasUsed,
+			Time:        h.Time,
+			Extra:       h.Extra,
+			MixDigest:   h.MixDigest,
+			Nonce:       gethtypes.BlockNonce(h.Nonce),
+			BaseFee:     h.BaseFee,
+		}
+		have, _ := rlp.EncodeToBytes(h)
+		want, _ := rlp.EncodeToBytes(upstream)
+		if !bytes.Equal(have, want) {
+			t.Errorf("base fee %v: wrong encoding: have %x, want %x", baseFee, have, want)
+		}
+		if h.Hash() != upstream.Hash() {
+			t.Errorf("base fee %v: wrong hash: have %v, want %v", baseFee, h.Hash(), upstream.Hash())
+		}
+		if withShutterFields(h).Hash() == h.Hash() {
+			t.Errorf("base fee %v: hash does not cover the Shutter fields", baseFee)
+		}
+	}
+}
+
+func TestHeaderShutterFieldsRoundTrip(t *testing.T) {
+	for _, h := range []*Header{newTestHeader(big.NewInt(1)), withShutterFields(newTestHeader(big.NewInt(1)))} {
+		enc, err := rlp.EncodeToBytes(h)
+		if err != nil {
+			t.Fatalf("failed to encode header: %v", err)
+		}
+		var dec Header
+		if err := rlp.DecodeBytes(enc, &dec); err != nil {
+			t.Fatalf("failed to decode header: %v", err)
+		}
+		checkShutterFields(t, "rlp", &dec, h)
+
+		js, err := json.Marshal(h)
+		if err != nil {
+			t.Fatalf("failed to encode header: %v", err)
+		}
+		dec = Header{}
+		if err := json.Unmarshal(js, &dec); err != nil {
+			t.Fatalf("failed to decode header: %v", err)
+		}
+		checkShutterFields(t, "json", &dec, h)
+	}
+}
+
+func checkShutterFields(t *testing.T, encoding string, have, want *Header) {
+	t.Helper()
+	if have.Hash() != want.Hash() || have.IsShutter() != want.IsShutter() {
+		t.Errorf("%s: wrong decoded header: hash %v, want %v", encoding, have.Hash(), want.Hash())
+	}
+	if !want.IsShutter() {
+		if have.BatchIndex != nil || have.L1BlockNumber != nil || have.DecryptionKeyHash != nil {
+			t.Errorf("%s: unexpected Shutter fields", encoding)
+		}
+		return
+	}
+	if *have.BatchIndex != *want.BatchIndex || *have.L1BlockNumber != *want.L1BlockNumber || *have.DecryptionKeyHash != *want.DecryptionKeyHash {
+		t.Errorf("%s: wrong Shutter fields: %d, %d, %v", encoding, *have.BatchIndex, *have.L1BlockNumber, *have.DecryptionKeyHash)
+	}
+}
+
+func TestHeaderSanityCheckShutterFields(t *testing.T) {
+	complete := withShutterFields(newTestHeader(big.NewInt(1)))
+	noKeyHash := CopyHeader(complete)
+	noKeyHash.DecryptionKeyHash = nil
+	onlyL1 := newTestHeader(big.NewInt(1))
+	onlyL1.L1BlockNumber = complete.L1BlockNumber
+	noBaseFee := withShutterFields(newTestHeader(nil))
+
+	tests := []struct {
+		header  *Header
+		shutter bool
+		valid   bool
+	}{
+		{newTestHeader(nil), false, true},
+		{newTestHeader(big.NewInt(1)), false, true},
+		{complete, true, true},
+		{noKeyHash, true, false},
+		{onlyL1, true, false},
+		{noBaseFee, true, false},
+	}
+	for i, tt := range tests {
+		if tt.header.IsShutter() != tt.shutter {
+			t.Errorf("test %d: wrong IsShutter: have %t, want %t", i, tt.header.IsShutter(), tt.shutter)
+		}
+		if err := tt.header.SanityCheck(); (err == nil) != tt.valid {
+			t.Errorf("test %d: wrong sanity check result: have %v, want valid %t", i, err, tt.valid)
+		}
+	}
+}
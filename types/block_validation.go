@@ -0,0 +1,106 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	ErrShutterHeaderFieldsMissing    = errors.New("shutter header fields missing")
+	ErrShutterHeaderFieldsUnexpected = errors.New("shutter header fields before shutter fork")
+	ErrBatchTxMissing                = errors.New("first transaction is not a batch transaction")
+	ErrBatchTxNotFirst               = errors.New("batch transaction not at the start of the block")
+	ErrBatchIndexMismatch            = errors.New("batch index differs from header")
+	ErrL1BlockNumberMismatch         = errors.New("l1 block number differs from header")
+	ErrDecryptionKeyHashMismatch     = errors.New("decryption key hash differs from header")
+	ErrTxRootMismatch                = errors.New("transactions root differs from header")
+	ErrReceiptCountMismatch          = errors.New("receipt count differs from transaction count")
+	ErrReceiptTypeMismatch           = errors.New("receipt type differs from transaction type")
+	ErrReceiptRootMismatch           = errors.New("receipts root differs from header")
+)
+
+// ShutterBlockError is returned when a block violates the structural rules of
+// a Shutter chain.
+type ShutterBlockError struct {
+	Number uint64 // Number of the invalid block
+	Index  int    // Index of the offending transaction or receipt, -1 for the header
+	Err    error
+}
+
+func (e *ShutterBlockError) Error() string {
+	if e.Index < 0 {
+		return fmt.Sprintf("invalid shutter block %d: %v", e.Number, e.Err)
+	}
+	return fmt.Sprintf("invalid shutter block %d: transaction %d: %v", e.Number, e.Index, e.Err)
+}
+
+func (e *ShutterBlockError) Unwrap() error {
+	return e.Err
+}
+
+// ValidateShutterBlock checks the structure of a block without executing it.
+// Before the Shutter fork, the header must not contain the Shutter fields. After
+// the fork, the block must start with the only batch transaction of the block,
+// which matches the batch fields of the header, all Shutter transactions must
+// belong to that batch and the transactions must match the transactions root.
+//
+// If receipts is not nil, the receipts must match the transactions and the
+// receipts root as well. Light clients which have not executed the block yet
+// can pass nil.
+func ValidateShutterBlock(block *Block, receipts Receipts, config *ShutterChainConfig) error {
+	header := block.header
+	fail := func(index int, err error) error {
+		return &ShutterBlockError{Number: block.NumberU64(), Index: index, Err: err}
+	}
+	if !config.IsShutter(block.Number()) {
+		if header.IsShutter() {
+			return fail(-1, ErrShutterHeaderFieldsUnexpected)
+		}
+		return nil
+	}
+	if !header.hasShutterFields() {
+		return fail(-1, ErrShutterHeaderFieldsMissing)
+	}
+	txs := block.Transactions()
+	if len(txs) == 0 || txs[0].Type() != BatchTxType {
+		return fail(0, ErrBatchTxMissing)
+	}
+	batch := txs[0]
+	if batch.BatchIndex() != *header.BatchIndex {
+		return fail(0, ErrBatchIndexMismatch)
+	}
+	if batch.L1BlockNumber() != *header.L1BlockNumber {
+		return fail(0, ErrL1BlockNumberMismatch)
+	}
+	if crypto.Keccak256Hash(batch.DecryptionKey()) != *header.DecryptionKeyHash {
+		return fail(0, ErrDecryptionKeyHashMismatch)
+	}
+	for i, tx := range txs[1:] {
+		switch {
+		case tx.Type() == BatchTxType:
+			return fail(i+1, ErrBatchTxNotFirst)
+		case tx.Type() == ShutterTxType && tx.BatchIndex() != *header.BatchIndex:
+			return fail(i+1, ErrWrongBatchIndex)
+		}
+	}
+	if DeriveSha(txs, NewStackTrie()) != header.TxHash {
+		return fail(-1, ErrTxRootMismatch)
+	}
+	if receipts == nil {
+		return nil
+	}
+	if len(receipts) != len(txs) {
+		return fail(-1, ErrReceiptCountMismatch)
+	}
+	for i, receipt := range receipts {
+		if receipt.Type != txs[i].Type() {
+			return fail(i, ErrReceiptTypeMismatch)
+		}
+	}
+	if DeriveSha(receipts, NewStackTrie()) != header.ReceiptHash {
+		return fail(-1, ErrReceiptRootMismatch)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package types
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestValidateShutterBlock(t *testing.T) {
+	config := func() *ShutterChainConfig {
+		config := *params.AllEthashProtocolChanges
+		config.ChainID = testChainID
+		return &ShutterChainConfig{ChainConfig: &config, ShutterBlock: big.NewInt(10)}
+	}()
+	signer := NewShutterSigner(testChainID)
+	key := AESTestScheme{}.DecryptionKey(testEonKey, 5)
+	newBatch := func(batchIndex, l1BlockNumber uint64, key []byte) *Transaction {
+		return MustSignNewTx(testKey, signer, &BatchTx{ChainID: testChainID, DecryptionKey: key, BatchIndex: batchIndex, L1BlockNumber: l1BlockNumber, Timestamp: big.NewInt(0)})
+	}
+	var (
+		batch   = newBatch(5, 7, key)
+		shutter = newTestShutterTx(t, testShutterPay, 5, 0)
+		plain   = MustSignNewTx(testKey, signer, &DynamicFeeTx{ChainID: testChainID, Nonce: 1, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(1), Gas: 21000, To: &testRecipient, Value: big.NewInt(0)})
+		txs     = Transactions{batch, shutter, plain}
+	)
+	receiptsFor := func(txs Transactions) Receipts {
+		receipts := make(Receipts, len(txs))
+		for i, tx := range txs {
+			receipts[i] = &Receipt{Type: tx.Type(), Status: ReceiptStatusSuccessful, CumulativeGasUsed: uint64(i) * 21000, Logs: []*Log{}}
+		}
+		return receipts
+	}
+	// newBlock creates a block at the given number committing to the batch
+	// fields, transactions and receipts.
+	newBlock := func(number int64, batchIndex, l1BlockNumber *uint64, keyHash *common.Hash, txs Transactions, receipts Receipts) *Block {
+		header := newTestHeader(big.NewInt(1))
+		header.Number = big.NewInt(number)
+		header.BatchIndex, header.L1BlockNumber, header.DecryptionKeyHash = batchIndex, l1BlockNumber, keyHash
+		return NewBlock(header, txs, nil, receipts, NewStackTrie())
+	}
+	u64 := func(n uint64) *uint64 { return &n }
+	keyHash := crypto.Keccak256Hash(key)
+	// A receipt differing in a consensus field only changes the receipts root.
+	failed := receiptsFor(txs)
+	failed[2].Status = ReceiptStatusFailed
+
+	tests := []struct {
+		name     string
+		block    *Block
+		receipts Receipts
+		err      error
+		index    int
+	}{
+		{name: "valid", block: newBlock(10, u64(5), u64(7), &keyHash, txs, receiptsFor(txs)), receipts: receiptsFor(txs)},
+		{name: "valid without receipts", block: newBlock(10, u64(5), u64(7), &keyHash, txs, receiptsFor(txs))},
+		{name: "valid before fork", block: newBlock(9, nil, nil, nil, Transactions{plain}, nil)},
+		{name: "fields before fork", block: newBlock(9, u64(5), u64(7), &keyHash, txs, nil), err: ErrShutterHeaderFieldsUnexpected, index: -1},
+		{name: "partial fields before fork", block: newBlock(9, nil, u64(7), nil, Transactions{plain}, nil), err: ErrShutterHeaderFieldsUnexpected, index: -1},
+		{name: "missing fields", block: newBlock(10, nil, nil, nil, txs, nil), err: ErrShutterHeaderFieldsMissing, index: -1},
+		{name: "missing key hash", block: newBlock(10, u64(5), u64(7), nil, txs, nil), err: ErrShutterHeaderFieldsMissing, index: -1},
+		{name: "no transactions", block: newBlock(10, u64(5), u64(7), &keyHash, nil, nil), err: ErrBatchTxMissing, index: 0},
+		{name: "batch not first", block: newBlock(10, u64(5), u64(7), &keyHash, Transactions{shutter, batch}, nil), err: ErrBatchTxMissing, index: 0},
+		{name: "batch index mismatch", block: newBlock(10, u64(6), u64(7), &keyHash, txs, nil), err: ErrBatchIndexMismatch, index: 0},
+		{name: "l1 block mismatch", block: newBlock(10, u64(5), u64(8), &keyHash, txs, nil), err: ErrL1BlockNumberMismatch, index: 0},
+		{name: "key hash mismatch", block: newBlock(10, u64(5), u64(7), &common.Hash{0x01}, txs, nil), err: ErrDecryptionKeyHashMismatch, index: 0},
+		{name: "second batch", block: newBlock(10, u64(5), u64(7), &keyHash, Transactions{batch, shutter, newBatch(6, 7, key)}, nil), err: ErrBatchTxNotFirst, index: 2},
+		{name: "shutter tx of other batch", block: newBlock(10, u64(5), u64(7), &keyHash, Transactions{batch, plain, newTestShutterTx(t, testShutterPay, 4, 0)}, nil), err: ErrWrongBatchIndex, index: 2},
+		{name: "tx root mismatch", block: newBlock(10, u64(5), u64(7), &keyHash, txs, nil).WithBody(txs[:2], nil), err: ErrTxRootMismatch, index: -1},
+		{name: "receipt count mismatch", block: newBlock(10, u64(5), u64(7), &keyHash, txs, receiptsFor(txs)), receipts: receiptsFor(txs[:2]), err: ErrReceiptCountMismatch, index: -1},
+		{name: "receipt type mismatch", block: newBlock(10, u64(5), u64(7), &keyHash, txs, receiptsFor(txs)), receipts: receiptsFor(Transactions{batch, plain, plain}), err: ErrReceiptTypeMismatch, index: 1},
+		{name: "receipt root mismatch", block: newBlock(10, u64(5), u64(7), &keyHash, txs, receiptsFor(txs)), receipts: failed, err: ErrReceiptRootMismatch, index: -1},
+	}
+
+	for _, tt := range tests {
+		err := ValidateShutterBlock(tt.block, tt.receipts, config)
+		if tt.err == nil {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tt.name, err)
+			}
+			continue
+		}
+		var blockErr *ShutterBlockError
+		if !errors.Is(err, tt.err) || !errors.As(err, &blockErr) {
+			t.Errorf("%s: wrong error: have %v, want %v", tt.name, err, tt.err)
+			continue
+		}
+		if blockErr.Index != tt.index || blockErr.Number != tt.block.NumberU64() {
+			t.Errorf("%s: wrong error location: have block %d index %d, want block %d index %d", tt.name, blockErr.Number, blockErr.Index, tt.block.NumberU64(), tt.index)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// ShutterChainConfig is the chain configuration of a Shutter chain. It extends
+// the upstream chain configuration with the activation of the Shutter fork.
+//
+// The embedded upstream configuration must be set, the methods of
+// ShutterChainConfig and the functions taking it, like MakeSigner and
+// LatestSigner, panic otherwise. CheckConfigForkOrder reports a missing one.
+type ShutterChainConfig struct {
+	*params.ChainConfig
+
+	ShutterBlock          *big.Int `json:"shutterBlock,omitempty"`          // Shutter switch block (nil = no fork, 0 = already activated)
+	ShutterSigningV2Block *big.Int `json:"shutterSigningV2Block,omitempty"` // ShutterSigningV2 switch block (nil = no fork, 0 = already activated)
+}
+
+// IsShutter returns whether num is either equal to the Shutter fork block or greater.
+func (c *ShutterChainConfig) IsShutter(num *big.Int) bool {
+	return isForked(c.ShutterBlock, num)
+}
+
+// IsShutterSigningV2 returns whether num is either equal to the ShutterSigningV2
+// fork block or greater.
+func (c *ShutterChainConfig) IsShutterSigningV2(num *big.Int) bool {
+	return isForked(c.ShutterSigningV2Block, num)
+}
+
+// CheckConfigForkOrder checks that the upstream forks are ordered and that the
+// Shutter forks follow them: the Shutter fork requires London, and
+// ShutterSigningV2 requires the Shutter fork. Otherwise a misconfigured
+// ShutterSigningV2 fork would enable the Shutter transaction types early.
+func (c *ShutterChainConfig) CheckConfigForkOrder() error {
+	if c.ChainConfig == nil {
+		return errors.New("missing upstream chain config")
+	}
+	if err := c.ChainConfig.CheckConfigForkOrder(); err != nil {
+		return err
+	}
+	type fork struct {
+		name  string
+		block *big.Int
+	}
+	last := fork{name: "londonBlock", block: c.LondonBlock}
+	for _, cur := range []fork{
+		{name: "shutterBlock", block: c.ShutterBlock},
+		{name: "shutterSigningV2Block", block: c.ShutterSigningV2Block},
+	} {
+		if last.block == nil && cur.block != nil {
+			return fmt.Errorf("unsupported fork ordering: %v not enabled, but %v enabled at %v",
+				last.name, cur.name, cur.block)
+		}
+		if last.block != nil && cur.block != nil && last.block.Cmp(cur.block) > 0 {
+			return fmt.Errorf("unsupported fork ordering: %v enabled at %v, but %v enabled at %v",
+				last.name, last.block, cur.name, cur.block)
+		}
+		last = cur
+	}
+	if c.ShutterBlock != nil && c.ChainID == nil {
+		return errors.New("shutterBlock enabled without chain id")
+	}
+	return nil
+}
+
+// isForked returns whether a fork scheduled at block s is active at the given head block.
+func isForked(s, head *big.Int) bool {
+	if s == nil || head == nil {
+		return false
+	}
+	return s.Cmp(head) <= 0
+}
@@ -0,0 +1,40 @@
+package types
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestShutterChainConfigForkOrder(t *testing.T) {
+	london := func(block int64) *params.ChainConfig {
+		config := *params.AllEthashProtocolChanges
+		config.LondonBlock = big.NewInt(block)
+		return &config
+	}
+	tests := []struct {
+		config *ShutterChainConfig
+		valid  bool
+	}{
+		{&ShutterChainConfig{ChainConfig: london(0)}, true},
+		{&ShutterChainConfig{ChainConfig: london(0), ShutterBlock: big.NewInt(0), ShutterSigningV2Block: big.NewInt(0)}, true},
+		{&ShutterChainConfig{ChainConfig: london(5), ShutterBlock: big.NewInt(10), ShutterSigningV2Block: big.NewInt(20)}, true},
+		{&ShutterChainConfig{ChainConfig: london(5), ShutterBlock: big.NewInt(10)}, true},
+		// Missing upstream config.
+		{&ShutterChainConfig{ShutterBlock: big.NewInt(0)}, false},
+		// Shutter before or without London.
+		{&ShutterChainConfig{ChainConfig: london(10), ShutterBlock: big.NewInt(5)}, false},
+		{&ShutterChainConfig{ChainConfig: &params.ChainConfig{ChainID: big.NewInt(1)}, ShutterBlock: big.NewInt(5)}, false},
+		// ShutterSigningV2 before or without Shutter.
+		{&ShutterChainConfig{ChainConfig: london(0), ShutterBlock: big.NewInt(10), ShutterSigningV2Block: big.NewInt(5)}, false},
+		{&ShutterChainConfig{ChainConfig: london(0), ShutterSigningV2Block: big.NewInt(5)}, false},
+		// Shutter without chain id.
+		{&ShutterChainConfig{ChainConfig: &params.ChainConfig{LondonBlock: big.NewInt(0)}, ShutterBlock: big.NewInt(0)}, false},
+	}
+	for i, tt := range tests {
+		if err := tt.config.CheckConfigForkOrder(); (err == nil) != tt.valid {
+			t.Errorf("test %d: wrong result: have %v, want valid %t", i, err, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package types
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	ErrDecryptionFailed     = errors.New("failed to decrypt shutter payload")
+	ErrInvalidDecryptionKey = errors.New("decryption key does not match eon key and batch index")
+)
+
+// EonKey is the public key of a keyper eon. Payloads of Shutter transactions
+// are encrypted for an eon key.
+type EonKey []byte
+
+// Decrypter decrypts the encrypted payload of Shutter transactions with the
+// decryption key that is published for their batch.
+type Decrypter interface {
+	Decrypt(key []byte, encryptedPayload []byte) ([]byte, error)
+}
+
+// EncryptionScheme is the scheme used to encrypt the payload of Shutter
+// transactions. A payload is encrypted for an eon key and a batch index and can
+// only be decrypted with the decryption key of that batch.
+type EncryptionScheme interface {
+	Decrypter
+	Encrypt(eonKey EonKey, batchIndex uint64, payload []byte) ([]byte, error)
+}
+
+// DecryptionKeyVerifier checks that a decryption key belongs to a batch. The
+// production implementation is a pairing check against the eon key, tests can
+// use a local scheme such as AESTestScheme.
+type DecryptionKeyVerifier interface {
+	// VerifyDecryptionKey returns ErrInvalidDecryptionKey if key is not the
+	// decryption key for the batch with the given index.
+	VerifyDecryptionKey(eonKey EonKey, batchIndex uint64, key []byte) error
+}
+
+// VerifyDecryptionKey checks that the decryption key of a batch transaction is
+// the key for its batch index. A batch carrying an invalid key must be rejected,
+// as none of its Shutter transactions could be decrypted.
+func (tx *Transaction) VerifyDecryptionKey(eonKey EonKey, verifier DecryptionKeyVerifier) error {
+	if tx.Type() != BatchTxType {
+		return ErrInvalidTxType
+	}
+	return verifier.VerifyDecryptionKey(eonKey, tx.BatchIndex(), tx.DecryptionKey())
+}
+
+// Decrypt returns a copy of the Shutter transaction with its payload set to the
+// decrypted EncryptedPayload, so that To, Value and Data return the real values.
+// The hash of the transaction is not affected by decryption.
+func (tx *Transaction) Decrypt(key []byte, scheme Decrypter) (*Transaction, error) {
+	inner, ok := tx.inner.(*ShutterTx)
+	if !ok {
+		return nil, ErrInvalidTxType
+	}
+	b, err := scheme.Decrypt(key, inner.EncryptedPayload)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := DecodeShutterPayload(b)
+	if err != nil {
+		return nil, err
+	}
+	cpy := inner.copy().(*ShutterTx)
+	cpy.Payload = payload
+	cpy.DecryptionStatus = DecryptionStatusDecrypted
+	return &Transaction{inner: cpy, time: tx.time}, nil
+}
+
+// DecryptionStatus returns the outcome of decrypting a Shutter transaction. It
+// is DecryptionStatusNone for other transactions and for Shutter transactions
+// which have not been decrypted yet.
+func (tx *Transaction) DecryptionStatus() DecryptionStatus {
+	if inner, ok := tx.inner.(*ShutterTx); ok {
+		return inner.DecryptionStatus
+	}
+	return DecryptionStatusNone
+}
+
+// NewEncryptedShutterTx encrypts the payload for the given eon key and batch
+// index and returns an unsigned Shutter transaction carrying it.
+func NewEncryptedShutterTx(payload *ShutterPayload, eonKey EonKey, batchIndex, l1BlockNumber uint64, chainID *big.Int, nonce uint64, gasTipCap, gasFeeCap *big.Int, gas uint64, scheme EncryptionScheme) (*Transaction, error) {
+	inner, err := newEncryptedShutterTx(payload, eonKey, batchIndex, l1BlockNumber, chainID, nonce, gasTipCap, gasFeeCap, gas, scheme)
+	if err != nil {
+		return nil, err
+	}
+	return NewTx(inner), nil
+}
+
+// SignNewEncryptedShutterTx encrypts the payload like NewEncryptedShutterTx
+// and signs the resulting transaction. The signer must use the Shutter signing
+// version that is active when the transaction is included, as returned by
+// MakeSigner, otherwise the chain rejects the signature.
+func SignNewEncryptedShutterTx(prv *ecdsa.PrivateKey, s Signer, payload *ShutterPayload, eonKey EonKey, batchIndex, l1BlockNumber uint64, nonce uint64, gasTipCap, gasFeeCap *big.Int, gas uint64, scheme EncryptionScheme) (*Transaction, error) {
+	inner, err := newEncryptedShutterTx(payload, eonKey, batchIndex, l1BlockNumber, s.ChainID(), nonce, gasTipCap, gasFeeCap, gas, scheme)
+	if err != nil {
+		return nil, err
+	}
+	return SignNewTx(prv, s, inner)
+}
+
+func newEncryptedShutterTx(payload *ShutterPayload, eonKey EonKey, batchIndex, l1BlockNumber uint64, chainID *big.Int, nonce uint64, gasTipCap, gasFeeCap *big.Int, gas uint64, scheme EncryptionScheme) (*ShutterTx, error) {
+	b, err := payload.Encode()
+	if err != nil {
+		return nil, err
+	}
+	encryptedPayload, err := scheme.Encrypt(eonKey, batchIndex, b)
+	if err != nil {
+		return nil, err
+	}
+	return &ShutterTx{
+		ChainID:          chainID,
+		Nonce:            nonce,
+		GasTipCap:        gasTipCap,
+		GasFeeCap:        gasFeeCap,
+		Gas:              gas,
+		EncryptedPayload: encryptedPayload,
+		BatchIndex:       batchIndex,
+		L1BlockNumber:    l1BlockNumber,
+	}, nil
+}
+
+// AESTestScheme is a deterministic EncryptionScheme based on AES-GCM. The
+// decryption key of a batch is derived from the eon key and the batch index, so
+// anybody who knows the eon key can decrypt. Only use it for testing.
+type AESTestScheme struct{}
+
+// DecryptionKey returns the decryption key of the batch with the given index.
+func (AESTestScheme) DecryptionKey(eonKey EonKey, batchIndex uint64) []byte {
+	var index [8]byte
+	binary.BigEndian.PutUint64(index[:], batchIndex)
+	return crypto.Keccak256(eonKey, index[:])
+}
+
+// Encrypt encrypts the payload for the given batch. The nonce is derived from
+// the key and the payload, which makes the ciphertext deterministic.
+func (s AESTestScheme) Encrypt(eonKey EonKey, batchIndex uint64, payload []byte) ([]byte, error) {
+	key := s.DecryptionKey(eonKey, batchIndex)
+	aead, err := newTestSchemeAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := crypto.Keccak256(key, payload)[:aead.NonceSize()]
+	return aead.Seal(nonce, nonce, payload, nil), nil
+}
+
+// VerifyDecryptionKey checks that key is the decryption key of the batch.
+func (s AESTestScheme) VerifyDecryptionKey(eonKey EonKey, batchIndex uint64, key []byte) error {
+	if subtle.ConstantTimeCompare(key, s.DecryptionKey(eonKey, batchIndex)) != 1 {
+		return ErrInvalidDecryptionKey
+	}
+	return nil
+}
+
+// Decrypt decrypts a payload that was encrypted with Encrypt.
+func (AESTestScheme) Decrypt(key []byte, encryptedPayload []byte) ([]byte, error) {
+	aead, err := newTestSchemeAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(encryptedPayload) < aead.NonceSize() {
+		return nil, ErrDecryptionFailed
+	}
+	nonce, ciphertext := encryptedPayload[:aead.NonceSize()], encryptedPayload[aead.NonceSize():]
+	payload, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	return payload, nil
+}
+
+func newTestSchemeAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(crypto.Keccak256(key))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,124 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	testEonKey     = EonKey("test eon key")
+	testChainID    = big.NewInt(1)
+	testKey, _     = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddr       = crypto.PubkeyToAddress(testKey.PublicKey)
+	testRecipient  = common.HexToAddress("0x095e7baea6a6c7c4c2dfeb977efac326af552d87")
+	testShutterPay = &ShutterPayload{To: &testRecipient, Data: []byte{0xde, 0xad, 0xbe, 0xef}, Value: big.NewInt(42)}
+)
+
+// newTestShutterTx encrypts the payload for the batch and signs the resulting
+// Shutter transaction with testKey.
+func newTestShutterTx(t *testing.T, payload *ShutterPayload, batchIndex, nonce uint64) *Transaction {
+	t.Helper()
+	tx, err := SignNewEncryptedShutterTx(testKey, NewShutterSigner(testChainID), payload, testEonKey, batchIndex, 7, nonce, big.NewInt(1), big.NewInt(10), 100000, AESTestScheme{})
+	if err != nil {
+		t.Fatalf("failed to create shutter tx: %v", err)
+	}
+	return tx
+}
+
+func TestDecryptShutterTx(t *testing.T) {
+	tx := newTestShutterTx(t, testShutterPay, 3, 0)
+	if tx.To() != nil || tx.Value().Sign() != 0 || len(tx.Data()) != 0 {
+		t.Fatalf("encrypted tx exposes payload: to %v, value %v, data %x", tx.To(), tx.Value(), tx.Data())
+	}
+	if status := tx.DecryptionStatus(); status != DecryptionStatusNone {
+		t.Fatalf("wrong status before decryption: %v", status)
+	}
+
+	key := AESTestScheme{}.DecryptionKey(testEonKey, 3)
+	decrypted, err := tx.Decrypt(key, AESTestScheme{})
+	if err != nil {
+		t.Fatalf("failed to decrypt: %v", err)
+	}
+	if to := decrypted.To(); to == nil || *to != testRecipient {
+		t.Errorf("wrong recipient: have %v, want %v", to, testRecipient)
+	}
+	if value := decrypted.Value(); value.Cmp(testShutterPay.Value) != 0 {
+		t.Errorf("wrong value: have %v, want %v", value, testShutterPay.Value)
+	}
+	if data := decrypted.Data(); !bytes.Equal(data, testShutterPay.Data) {
+		t.Errorf("wrong data: have %x, want %x", data, testShutterPay.Data)
+	}
+	if status := decrypted.DecryptionStatus(); status != DecryptionStatusDecrypted {
+		t.Errorf("wrong status after decryption: %v", status)
+	}
+	if tx.To() != nil || tx.DecryptionStatus() != DecryptionStatusNone {
+		t.Errorf("decryption modified the original transaction")
+	}
+}
+
+func TestDecryptNonShutterTx(t *testing.T) {
+	tx := NewTx(&DynamicFeeTx{ChainID: testChainID, To: &testRecipient})
+	if _, err := tx.Decrypt(nil, AESTestScheme{}); !errors.Is(err, ErrInvalidTxType) {
+		t.Fatalf("wrong error: have %v, want %v", err, ErrInvalidTxType)
+	}
+}
+
+func TestEncryptedShutterTxRoundTrip(t *testing.T) {
+	signer := NewShutterSigner(testChainID)
+	payloads := []*ShutterPayload{
+		testShutterPay,
+		{To: nil, Data: []byte{0x60, 0x80}, Value: big.NewInt(0)},
+		{To: &testRecipient, Data: []byte{}, Value: big.NewInt(1)},
+	}
+	for i, payload := range payloads {
+		unsigned, err := NewEncryptedShutterTx(payload, testEonKey, 9, 7, testChainID, uint64(i), big.NewInt(1), big.NewInt(10), 100000, AESTestScheme{})
+		if err != nil {
+			t.Fatalf("payload %d: failed to create tx: %v", i, err)
+		}
+		tx, err := SignNewEncryptedShutterTx(testKey, signer, payload, testEonKey, 9, 7, uint64(i), big.NewInt(1), big.NewInt(10), 100000, AESTestScheme{})
+		if err != nil {
+			t.Fatalf("payload %d: failed to sign tx: %v", i, err)
+		}
+		if !bytes.Equal(tx.EncryptedPayload(), unsigned.EncryptedPayload()) {
+			t.Fatalf("payload %d: signing changed the encrypted payload", i)
+		}
+		if tx.BatchIndex() != 9 || tx.L1BlockNumber() != 7 || tx.Nonce() != uint64(i) || tx.ChainId().Cmp(testChainID) != 0 {
+			t.Fatalf("payload %d: wrong transaction fields", i)
+		}
+		if from, err := Sender(signer, tx); err != nil || from != testAddr {
+			t.Fatalf("payload %d: wrong sender: have %v (%v), want %v", i, from, err, testAddr)
+		}
+
+		decrypted, err := tx.Decrypt(AESTestScheme{}.DecryptionKey(testEonKey, 9), AESTestScheme{})
+		if err != nil {
+			t.Fatalf("payload %d: failed to decrypt: %v", i, err)
+		}
+		if decrypted.Hash() != tx.Hash() {
+			t.Errorf("payload %d: decryption changed the hash: have %v, want %v", i, decrypted.Hash(), tx.Hash())
+		}
+		if to := decrypted.To(); (to == nil) != (payload.To == nil) || (to != nil && *to != *payload.To) {
+			t.Errorf("payload %d: wrong recipient: have %v, want %v", i, to, payload.To)
+		}
+		if !bytes.Equal(decrypted.Data(), payload.Data) || decrypted.Value().Cmp(payload.Value) != 0 {
+			t.Errorf("payload %d: wrong payload: have data %x value %v", i, decrypted.Data(), decrypted.Value())
+		}
+	}
+}
+
+func TestDecryptWrongKey(t *testing.T) {
+	tx := newTestShutterTx(t, testShutterPay, 9, 0)
+	for _, key := range [][]byte{
+		AESTestScheme{}.DecryptionKey(testEonKey, 10),
+		AESTestScheme{}.DecryptionKey(EonKey("other eon key"), 9),
+		nil,
+	} {
+		if _, err := tx.Decrypt(key, AESTestScheme{}); !errors.Is(err, ErrDecryptionFailed) {
+			t.Errorf("key %x: wrong error: have %v, want %v", key, err, ErrDecryptionFailed)
+		}
+	}
+}
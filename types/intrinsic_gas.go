@@ -0,0 +1,111 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	// ErrIntrinsicGas is returned if the gas limit of a transaction is below the
+	// intrinsic gas it requires.
+	ErrIntrinsicGas = errors.New("intrinsic gas too low")
+
+	// ErrGasUintOverflow is returned when calculating the intrinsic gas of a
+	// transaction overflows.
+	ErrGasUintOverflow = errors.New("gas uint64 overflow")
+)
+
+// ShutterTxDecryptionGas is the gas charged for decrypting the payload of a
+// Shutter transaction, on top of the regular transaction gas.
+const ShutterTxDecryptionGas uint64 = 25000
+
+// IntrinsicGas computes the intrinsic gas of a transaction under the given rules.
+// Batch transactions are system transactions and don't cost any gas. Shutter
+// transactions pay for the decryption and, until they are decrypted, for each
+// byte of the encrypted payload instead of the data. Whether a Shutter
+// transaction creates a contract is only known after decryption.
+func IntrinsicGas(tx *Transaction, rules params.Rules) (uint64, error) {
+	var (
+		gas  uint64
+		data []byte
+	)
+	switch inner := tx.inner.(type) {
+	case *BatchTx:
+		return 0, nil
+	case *ShutterTx:
+		gas = ShutterTxDecryptionGas
+		if inner.Payload != nil {
+			data = inner.Payload.Data
+		} else {
+			data = inner.EncryptedPayload
+		}
+	default:
+		data = tx.Data()
+	}
+	if isContractCreation(tx) && rules.IsHomestead {
+		gas += params.TxGasContractCreation
+	} else {
+		gas += params.TxGas
+	}
+	dataGas, err := intrinsicDataGas(data, rules.IsIstanbul)
+	if err != nil {
+		return 0, err
+	}
+	if (math.MaxUint64 - gas) < dataGas {
+		return 0, ErrGasUintOverflow
+	}
+	gas += dataGas
+
+	accessList := tx.AccessList()
+	if accessList != nil {
+		addressGas := uint64(len(accessList)) * params.TxAccessListAddressGas
+		storageGas := uint64(accessList.StorageKeys()) * params.TxAccessListStorageKeyGas
+		gas += addressGas + storageGas
+	}
+	return gas, nil
+}
+
+// intrinsicDataGas computes the gas charged for the bytes of the transaction
+// data, with the cheaper non-zero bytes of EIP-2028 if isEIP2028 is set.
+func intrinsicDataGas(data []byte, isEIP2028 bool) (uint64, error) {
+	if len(data) == 0 {
+		return 0, nil
+	}
+	// Zero and non-zero bytes are priced differently
+	var nz uint64
+	for _, byt := range data {
+		if byt != 0 {
+			nz++
+		}
+	}
+	nonZeroGas := params.TxDataNonZeroGasFrontier
+	if isEIP2028 {
+		nonZeroGas = params.TxDataNonZeroGasEIP2028
+	}
+	if (math.MaxUint64 / nonZeroGas) < nz {
+		return 0, ErrGasUintOverflow
+	}
+	gas := nz * nonZeroGas
+
+	z := uint64(len(data)) - nz
+	if (math.MaxUint64-gas)/params.TxDataZeroGas < z {
+		return 0, ErrGasUintOverflow
+	}
+	return gas + z*params.TxDataZeroGas, nil
+}
+
+// ValidateIntrinsicGas checks that the gas limit of the transaction covers its
+// intrinsic gas under the given rules.
+func ValidateIntrinsicGas(tx *Transaction, rules params.Rules) error {
+	gas, err := IntrinsicGas(tx, rules)
+	if err != nil {
+		return err
+	}
+	if tx.Gas() < gas {
+		return fmt.Errorf("%w: have %d, want %d", ErrIntrinsicGas, tx.Gas(), gas)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package types
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestIntrinsicGas(t *testing.T) {
+	accessList := AccessList{
+		{Address: testRecipient, StorageKeys: []common.Hash{{0x01}, {0x02}}},
+		{Address: testAddr, StorageKeys: []common.Hash{{0x03}}},
+	}
+	shutter := func(payload *ShutterPayload) func(gas uint64) TxInner {
+		return func(gas uint64) TxInner {
+			tx := &ShutterTx{ChainID: testChainID, Gas: gas, EncryptedPayload: []byte{0x00, 0x01, 0x02}, BatchIndex: 5}
+			if payload != nil {
+				tx.Payload, tx.DecryptionStatus = payload, DecryptionStatusDecrypted
+			}
+			return tx
+		}
+	}
+
+	tests := []struct {
+		name  string
+		rules params.Rules
+		inner func(gas uint64) TxInner
+		want  uint64
+	}{
+		{
+			name:  "legacy",
+			rules: testRules,
+			inner: func(gas uint64) TxInner { return &LegacyTx{Gas: gas, To: &testRecipient, Data: []byte{0x00, 0x01}} },
+			want:  params.TxGas + params.TxDataZeroGas + params.TxDataNonZeroGasEIP2028,
+		},
+		{
+			name:  "legacy before istanbul",
+			rules: params.Rules{IsHomestead: true},
+			inner: func(gas uint64) TxInner { return &LegacyTx{Gas: gas, To: &testRecipient, Data: []byte{0x00, 0x01}} },
+			want:  params.TxGas + params.TxDataZeroGas + params.TxDataNonZeroGasFrontier,
+		},
+		{
+			name:  "contract creation",
+			rules: testRules,
+			inner: func(gas uint64) TxInner { return &LegacyTx{Gas: gas, Data: []byte{0x01}} },
+			want:  params.TxGasContractCreation + params.TxDataNonZeroGasEIP2028,
+		},
+		{
+			name:  "contract creation before homestead",
+			rules: params.Rules{},
+			inner: func(gas uint64) TxInner { return &LegacyTx{Gas: gas, Data: []byte{0x01}} },
+			want:  params.TxGas + params.TxDataNonZeroGasFrontier,
+		},
+		{
+			name:  "access list",
+			rules: testRules,
+			inner: func(gas uint64) TxInner {
+				return &AccessListTx{ChainID: testChainID, Gas: gas, To: &testRecipient, AccessList: accessList}
+			},
+			want: params.TxGas + 2*params.TxAccessListAddressGas + 3*params.TxAccessListStorageKeyGas,
+		},
+		{
+			name:  "dynamic fee",
+			rules: testRules,
+			inner: func(gas uint64) TxInner {
+				return &DynamicFeeTx{ChainID: testChainID, Gas: gas, To: &testRecipient, Data: []byte{0x01}, AccessList: accessList[1:]}
+			},
+			want: params.TxGas + params.TxDataNonZeroGasEIP2028 + params.TxAccessListAddressGas + params.TxAccessListStorageKeyGas,
+		},
+		{
+			name:  "shutter before decryption",
+			rules: testRules,
+			inner: shutter(nil),
+			want:  ShutterTxDecryptionGas + params.TxGas + params.TxDataZeroGas + 2*params.TxDataNonZeroGasEIP2028,
+		},
+		{
+			name:  "shutter after decryption",
+			rules: testRules,
+			inner: shutter(testShutterPay),
+			want:  ShutterTxDecryptionGas + params.TxGas + 4*params.TxDataNonZeroGasEIP2028,
+		},
+		{
+			name:  "shutter contract creation",
+			rules: testRules,
+			inner: shutter(&ShutterPayload{Data: []byte{0x01}, Value: big.NewInt(0)}),
+			want:  ShutterTxDecryptionGas + params.TxGasContractCreation + params.TxDataNonZeroGasEIP2028,
+		},
+		{
+			name:  "batch",
+			rules: testRules,
+			inner: func(uint64) TxInner {
+				return &BatchTx{ChainID: testChainID, BatchIndex: 5, Timestamp: big.NewInt(0), Transactions: [][]byte{{0x01}}}
+			},
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		tx := NewTx(tt.inner(tt.want))
+		if have, err := IntrinsicGas(tx, tt.rules); err != nil || have != tt.want {
+			t.Errorf("%s: wrong intrinsic gas: have %d (%v), want %d", tt.name, have, err, tt.want)
+		}
+		if err := ValidateIntrinsicGas(tx, tt.rules); err != nil {
+			t.Errorf("%s: gas limit at intrinsic gas rejected: %v", tt.name, err)
+		}
+		if tt.want == 0 {
+			continue
+		}
+		if err := ValidateIntrinsicGas(NewTx(tt.inner(tt.want-1)), tt.rules); !errors.Is(err, ErrIntrinsicGas) {
+			t.Errorf("%s: wrong error below intrinsic gas: have %v, want %v", tt.name, err, ErrIntrinsicGas)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package types
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// MemoryTrie is a TrieHasher that computes the root hash of the Merkle-Patricia
+// trie holding the inserted key/value pairs, as used for the transaction and
+// receipt roots of block headers, without requiring a database.
+//
+// MemoryTrie keeps every inserted pair in memory and rebuilds the trie whenever
+// Hash or Prove is called, so keys may be inserted in any order, but its memory
+// use grows with the size of the whole trie. An empty value removes the key.
+// It is meant for creating proofs, use StackTrie to only compute roots.
+type MemoryTrie struct {
+	pairs map[string][]byte
+}
+
+// NewMemoryTrie creates an empty MemoryTrie.
+func NewMemoryTrie() *MemoryTrie {
+	return &MemoryTrie{pairs: make(map[string][]byte)}
+}
+
+// Reset removes all inserted pairs.
+func (t *MemoryTrie) Reset() {
+	t.pairs = make(map[string][]byte)
+}
+
+// Update inserts the key/value pair. Both are copied.
+func (t *MemoryTrie) Update(key, value []byte) {
+	if len(value) == 0 {
+		delete(t.pairs, string(key))
+		return
+	}
+	t.pairs[string(key)] = common.CopyBytes(value)
+}
+
+// Hash returns the root hash of the trie.
+func (t *MemoryTrie) Hash() common.Hash {
+	root := t.root()
+	if root == nil {
+		return EmptyRootHash
+	}
+	return crypto.Keccak256Hash(root.encode())
+}
+
+// Prove returns the encodings of the trie nodes on the path to the key, starting
+// with the root node. Nodes embedded into their parent are not included. If the
+// key is not in the trie, the nodes prove its absence. The proof can be checked
+// with VerifyProof.
+func (t *MemoryTrie) Prove(key []byte) [][]byte {
+	var proof [][]byte
+	hex := keybytesToHex(key)
+	n := t.root()
+	for n != nil {
+		if _, ok := n.(valueNode); ok {
+			break
+		}
+		if enc := n.encode(); len(proof) == 0 || len(enc) >= 32 {
+			proof = append(proof, enc)
+		}
+		switch nd := n.(type) {
+		case *shortNode:
+			if !bytes.HasPrefix(hex, nd.key) {
+				return proof
+			}
+			hex, n = hex[len(nd.key):], nd.val
+		case *fullNode:
+			hex, n = hex[1:], nd.children[hex[0]]
+		}
+	}
+	return proof
+}
+
+// root builds the trie from the inserted pairs and returns its root node, or
+// nil if the trie is empty.
+func (t *MemoryTrie) root() trieNode {
+	if len(t.pairs) == 0 {
+		return nil
+	}
+	keys := make([][]byte, 0, len(t.pairs))
+	for k := range t.pairs {
+		keys = append(keys, keybytesToHex([]byte(k)))
+	}
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+	values := make([][]byte, len(keys))
+	for i, k := range keys {
+		values[i] = t.pairs[string(hexToKeybytes(k))]
+	}
+	return buildTrieNode(keys, values, 0)
+}
+
+// trieNode is a node of a Merkle-Patricia trie.
+type trieNode interface {
+	encode() []byte
+}
+
+type (
+	// shortNode is a leaf if its value is a valueNode and an extension otherwise.
+	shortNode struct {
+		key []byte // hex encoded key, with terminator for leaves
+		val trieNode
+	}
+	// fullNode is a branch node, the 17th child holds the value of the key
+	// ending at the branch.
+	fullNode struct {
+		children [17]trieNode
+	}
+	valueNode []byte
+)
+
+func (n *shortNode) encode() []byte {
+	enc, _ := rlp.EncodeToBytes([]interface{}{hexToCompact(n.key), nodeRef(n.val)})
+	return enc
+}
+
+func (n *fullNode) encode() []byte {
+	var refs [17]interface{}
+	for i, child := range n.children {
+		if child == nil {
+			refs[i] = []byte(nil)
+		} else {
+			refs[i] = nodeRef(child)
+		}
+	}
+	enc, _ := rlp.EncodeToBytes(refs[:])
+	return enc
+}
+
+func (n valueNode) encode() []byte {
+	enc, _ := rlp.EncodeToBytes([]byte(n))
+	return enc
+}
+
+// nodeRef returns how a child node is referenced by its parent: nodes with an
+// encoding shorter than a hash are embedded, others are referenced by hash.
+func nodeRef(n trieNode) interface{} {
+	if v, ok := n.(valueNode); ok {
+		return []byte(v)
+	}
+	enc := n.encode()
+	if len(enc) < 32 {
+		return rlp.RawValue(enc)
+	}
+	return crypto.Keccak256(enc)
+}
+
+// buildTrieNode returns the node holding the given pairs, whose hex encoded keys
+// are sorted and share the first depth nibbles.
+func buildTrieNode(keys, values [][]byte, depth int) trieNode {
+	if len(keys) == 1 {
+		return &shortNode{key: keys[0][depth:], val: valueNode(values[0])}
+	}
+	// As the keys are sorted, the prefix shared by all of them is the prefix
+	// shared by the first and the last one.
+	first, last := keys[0][depth:], keys[len(keys)-1][depth:]
+	prefix := 0
+	for prefix < len(first) && prefix < len(last) && first[prefix] == last[prefix] {
+		prefix++
+	}
+	if prefix > 0 {
+		return &shortNode{key: first[:prefix], val: buildTrieNode(keys, values, depth+prefix)}
+	}
+	n := new(fullNode)
+	for start := 0; start < len(keys); {
+		nibble := keys[start][depth]
+		end := start + 1
+		for end < len(keys) && keys[end][depth] == nibble {
+			end++
+		}
+		if nibble == 16 {
+			n.children[16] = valueNode(values[start])
+		} else {
+			n.children[nibble] = buildTrieNode(keys[start:end], values[start:end], depth+1)
+		}
+		start = end
+	}
+	return n
+}
+
+// keybytesToHex returns the nibbles of the key followed by the terminator 16.
+func keybytesToHex(str []byte) []byte {
+	l := len(str)*2 + 1
+	nibbles := make([]byte, l)
+	for i, b := range str {
+		nibbles[i*2] = b / 16
+		nibbles[i*2+1] = b % 16
+	}
+	nibbles[l-1] = 16
+	return nibbles
+}
+
+// hexToKeybytes turns terminated hex nibbles back into key bytes.
+func hexToKeybytes(hex []byte) []byte {
+	hex = hex[:len(hex)-1]
+	key := make([]byte, len(hex)/2)
+	for i := range key {
+		key[i] = hex[2*i]<<4 | hex[2*i+1]
+	}
+	return key
+}
+
+// hexToCompact returns the compact (hex-prefix) encoding of hex nibbles.
+func hexToCompact(hex []byte) []byte {
+	terminator := byte(0)
+	if len(hex) > 0 && hex[len(hex)-1] == 16 {
+		terminator = 1
+		hex = hex[:len(hex)-1]
+	}
+	buf := make([]byte, len(hex)/2+1)
+	buf[0] = terminator << 5 // the flag byte
+	if len(hex)&1 == 1 {
+		buf[0] |= 1 << 4 // odd flag
+		buf[0] |= hex[0] // first nibble is contained in the first byte
+		hex = hex[1:]
+	}
+	for bi, ni := 0, 0; ni < len(hex); bi, ni = bi+1, ni+2 {
+		buf[bi+1] = hex[ni]<<4 | hex[ni+1]
+	}
+	return buf
+}
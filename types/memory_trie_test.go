@@ -0,0 +1,104 @@
+package types
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// newTrieTestTxs returns n unsigned transactions cycling through the legacy,
+// access list and dynamic fee types.
+func newTrieTestTxs(n int) Transactions {
+	txs := make(Transactions, n)
+	for i := range txs {
+		var inner TxInner
+		switch i % 3 {
+		case 0:
+			inner = &LegacyTx{Nonce: uint64(i), GasPrice: big.NewInt(int64(i + 1)), Gas: 21000, To: &testRecipient, Value: big.NewInt(int64(i)), Data: make([]byte, i%40), V: big.NewInt(27), R: big.NewInt(int64(i + 1)), S: big.NewInt(int64(i + 2))}
+		case 1:
+			inner = &AccessListTx{ChainID: big.NewInt(1), Nonce: uint64(i), GasPrice: big.NewInt(int64(i + 1)), Gas: 21000, To: &testRecipient, Value: big.NewInt(int64(i)), AccessList: AccessList{{Address: testRecipient, StorageKeys: []common.Hash{common.BigToHash(big.NewInt(int64(i)))}}}, V: big.NewInt(0), R: big.NewInt(int64(i + 1)), S: big.NewInt(int64(i + 2))}
+		case 2:
+			inner = &DynamicFeeTx{ChainID: big.NewInt(1), Nonce: uint64(i), GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(int64(i + 1)), Gas: 21000, To: nil, Value: big.NewInt(int64(i)), Data: []byte{byte(i)}, V: big.NewInt(1), R: big.NewInt(int64(i + 1)), S: big.NewInt(int64(i + 2))}
+		}
+		txs[i] = NewTx(inner)
+	}
+	return txs
+}
+
+// The roots were computed with types.DeriveSha and trie.NewStackTrie of
+// go-ethereum v1.10.8 for the transactions returned by newTrieTestTxs.
+var memoryTrieTestRoots = []struct {
+	n    int
+	root common.Hash
+}{
+	{0, EmptyRootHash},
+	{1, common.HexToHash("0x27974be4a61630f642ada9e3bceea8f7060a03ebdb3337b1641e573fc3669538")},
+	{2, common.HexToHash("0x1cf900899148078cbe7cf4ec049b46b4170a3561761f390a0a6143c5f76232ec")},
+	{3, common.HexToHash("0x3c56defbb8701a097cb8df7cfe8d3aa5014b5f677e891c96c9ebf57402769514")},
+	{16, common.HexToHash("0x250c6ffb907b79dc45f53cd5b8a36bd224396f7854416e8a4375aa07c11a2ab1")},
+	{17, common.HexToHash("0x13775c19153a826ebef19b4d071c0c97c4012f18814cfa4ef5001ec70eca2dcc")},
+	{128, common.HexToHash("0x3ae3e142d98a62f12a4a9983fa0421a08de9c5fb0d4de5146e3b0c16e17eeb43")},
+	{129, common.HexToHash("0xc42bab86cb6cba1d18eb9d3d749b04b5b67b5306e4b988ccc53d76d82c5fd5c7")},
+	{1000, common.HexToHash("0x197c7b2aa497631f78d86ab3bd142696661cbb76fae3a9b551cb45d1b631e060")},
+}
+
+func TestMemoryTrieUpstreamRoots(t *testing.T) {
+	for _, tt := range memoryTrieTestRoots {
+		if root := DeriveSha(newTrieTestTxs(tt.n), NewMemoryTrie()); root != tt.root {
+			t.Errorf("%d txs: wrong root: have %v, want %v", tt.n, root, tt.root)
+		}
+	}
+}
+
+func TestMemoryTrieUpdateOrder(t *testing.T) {
+	txs := newTrieTestTxs(17)
+	trie := NewMemoryTrie()
+	var buf bytes.Buffer
+	for i := len(txs) - 1; i >= 0; i-- {
+		buf.Reset()
+		txs.EncodeIndex(i, &buf)
+		trie.Update(rlp.AppendUint64(nil, uint64(i)), buf.Bytes())
+	}
+	// Inserting and removing an extra key must not change the root.
+	trie.Update([]byte("extra"), []byte("value"))
+	trie.Update([]byte("extra"), nil)
+	if root := trie.Hash(); root != memoryTrieTestRoots[5].root {
+		t.Fatalf("wrong root: have %v, want %v", root, memoryTrieTestRoots[5].root)
+	}
+	trie.Reset()
+	if root := trie.Hash(); root != EmptyRootHash {
+		t.Fatalf("wrong root after reset: have %v, want %v", root, EmptyRootHash)
+	}
+}
+
+func TestMemoryTrieProofs(t *testing.T) {
+	for _, tt := range memoryTrieTestRoots[1:] {
+		txs := newTrieTestTxs(tt.n)
+		for _, i := range []int{0, tt.n / 2, tt.n - 1} {
+			proof, err := proveDerivable(txs, i)
+			if err != nil {
+				t.Fatalf("%d txs: failed to prove tx %d: %v", tt.n, i, err)
+			}
+			value, err := VerifyProof(tt.root, rlp.AppendUint64(nil, uint64(i)), proof)
+			if err != nil {
+				t.Fatalf("%d txs: invalid proof for tx %d: %v", tt.n, i, err)
+			}
+			var buf bytes.Buffer
+			txs.EncodeIndex(i, &buf)
+			if !bytes.Equal(value, buf.Bytes()) {
+				t.Errorf("%d txs: wrong proven value for tx %d", tt.n, i)
+			}
+		}
+		// The proof for the index after the last transaction shows its absence.
+		trie := NewMemoryTrie()
+		DeriveSha(txs, trie)
+		key := rlp.AppendUint64(nil, uint64(tt.n))
+		value, err := VerifyProof(tt.root, key, trie.Prove(key))
+		if err != nil || value != nil {
+			t.Errorf("%d txs: wrong absence proof: value %x, err %v", tt.n, value, err)
+		}
+	}
+}
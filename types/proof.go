@@ -0,0 +1,155 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var (
+	ErrProofIndexOutOfRange = errors.New("index out of range of list")
+	ErrProofMissingNode     = errors.New("missing trie node in proof")
+	ErrInvalidProofNode     = errors.New("invalid trie node in proof")
+)
+
+// ProveTransaction returns the proof that the i'th transaction of the block is
+// included in its transactions root. The key of the transaction is the RLP
+// encoding of i and its value is the encoding returned by EncodeIndex.
+func ProveTransaction(block *Block, i int) ([][]byte, error) {
+	return proveDerivable(block.Transactions(), i)
+}
+
+// ProveReceipt returns the proof that the i'th receipt is included in the
+// receipts root of its block. The key of the receipt is the RLP encoding of i
+// and its value is the encoding returned by EncodeIndex.
+func ProveReceipt(receipts Receipts, i int) ([][]byte, error) {
+	return proveDerivable(receipts, i)
+}
+
+// proveDerivable returns the proof for the i'th element of the trie used by
+// DeriveSha.
+func proveDerivable(list DerivableList, i int) ([][]byte, error) {
+	if i < 0 || i >= list.Len() {
+		return nil, ErrProofIndexOutOfRange
+	}
+	trie := NewMemoryTrie()
+	DeriveSha(list, trie)
+	return trie.Prove(rlp.AppendUint64(nil, uint64(i))), nil
+}
+
+// VerifyProof checks the Merkle-Patricia proof for the key against the root and
+// returns the value stored under the key. If the proof shows that the key is not
+// in the trie, the returned value and error are nil.
+func VerifyProof(root common.Hash, key []byte, proof [][]byte) ([]byte, error) {
+	nodes := make(map[common.Hash][]byte, len(proof))
+	for _, enc := range proof {
+		nodes[crypto.Keccak256Hash(enc)] = enc
+	}
+	enc, ok := nodes[root]
+	if !ok {
+		return nil, fmt.Errorf("%w: %x", ErrProofMissingNode, root)
+	}
+	hex := keybytesToHex(key)
+	for {
+		elems, _, err := rlp.SplitList(enc)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidProofNode, err)
+		}
+		count, err := rlp.CountValues(elems)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidProofNode, err)
+		}
+		var ref []byte
+		switch count {
+		case 2:
+			compact, rest, err := rlp.SplitString(elems)
+			if err != nil || len(compact) == 0 || compact[0]>>4 > 3 {
+				return nil, ErrInvalidProofNode
+			}
+			nodeKey := compactToHex(compact)
+			if len(nodeKey) == 0 {
+				return nil, ErrInvalidProofNode
+			}
+			if !bytes.HasPrefix(hex, nodeKey) {
+				return nil, nil
+			}
+			hex = hex[len(nodeKey):]
+			if ref, err = rlpListItem(rest, 0); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidProofNode, err)
+			}
+			if len(hex) == 0 {
+				return proofValue(ref)
+			}
+		case 17:
+			if len(hex) == 0 {
+				return nil, ErrInvalidProofNode
+			}
+			if ref, err = rlpListItem(elems, int(hex[0])); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidProofNode, err)
+			}
+			if hex = hex[1:]; len(hex) == 0 {
+				return proofValue(ref)
+			}
+		default:
+			return nil, ErrInvalidProofNode
+		}
+		kind, content, _, err := rlp.Split(ref)
+		switch {
+		case err != nil:
+			return nil, fmt.Errorf("%w: %v", ErrInvalidProofNode, err)
+		case kind == rlp.List:
+			enc = ref
+		case kind == rlp.String && len(content) == 0:
+			return nil, nil
+		case kind == rlp.String && len(content) == common.HashLength:
+			hash := common.BytesToHash(content)
+			if enc, ok = nodes[hash]; !ok {
+				return nil, fmt.Errorf("%w: %x", ErrProofMissingNode, hash)
+			}
+		default:
+			return nil, ErrInvalidProofNode
+		}
+	}
+}
+
+// proofValue returns the value stored in the encoded value slot of a trie node.
+func proofValue(ref []byte) ([]byte, error) {
+	value, _, err := rlp.SplitString(ref)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidProofNode, err)
+	}
+	if len(value) == 0 {
+		return nil, nil
+	}
+	return value, nil
+}
+
+// rlpListItem returns the encoding of the i'th item of the list content elems.
+func rlpListItem(elems []byte, i int) ([]byte, error) {
+	for ; ; i-- {
+		_, _, rest, err := rlp.Split(elems)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			return elems[:len(elems)-len(rest)], nil
+		}
+		elems = rest
+	}
+}
+
+// compactToHex returns the hex nibbles of a compact (hex-prefix) encoded key.
+func compactToHex(compact []byte) []byte {
+	base := keybytesToHex(compact)
+	// delete terminator flag
+	if base[0] < 2 {
+		base = base[:len(base)-1]
+	}
+	// apply odd flag
+	chop := 2 - base[0]&1
+	return base[chop:]
+}
@@ -27,7 +27,6 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
@@ -41,6 +40,10 @@ var (
 // This error is returned when a typed receipt is decoded, but the string is empty.
 var errEmptyTypedReceipt = errors.New("empty typed receipt bytes")
 
+// This error is returned when a decryption status is decoded for a receipt of
+// a transaction that is not a Shutter transaction.
+var errUnexpectedDecryptionStatus = errors.New("decryption status in receipt of non-Shutter transaction")
+
 const (
 	// ReceiptStatusFailed is the status code of a transaction if execution failed.
 	ReceiptStatusFailed = uint64(0)
@@ -59,6 +62,10 @@ type Receipt struct {
 	Bloom             Bloom  `json:"logsBloom"         gencodec:"required"`
 	Logs              []*Log `json:"logs"              gencodec:"required"`
 
+	// DecryptionStatus is the outcome of decrypting a Shutter transaction. It is
+	// part of the consensus encoding of Shutter transaction receipts only.
+	DecryptionStatus DecryptionStatus `json:"decryptionStatus,omitempty"`
+
 	// Implementation fields: These fields are added by geth when processing a transaction.
 	// They are stored in the chain database.
 	TxHash          common.Hash    `json:"transactionHash" gencodec:"required"`
@@ -70,6 +77,11 @@ type Receipt struct {
 	BlockHash        common.Hash `json:"blockHash,omitempty"`
 	BlockNumber      *big.Int    `json:"blockNumber,omitempty"`
 	TransactionIndex uint        `json:"transactionIndex"`
+
+	// Shutter fields: These fields are derived from the Shutter or batch
+	// transaction corresponding to this receipt.
+	BatchIndex  uint64       `json:"batchIndex,omitempty"`
+	PayloadHash *common.Hash `json:"payloadHash,omitempty"`
 }
 
 type receiptMarshaling struct {
@@ -80,6 +92,8 @@ type receiptMarshaling struct {
 	GasUsed           hexutil.Uint64
 	BlockNumber       *hexutil.Big
 	TransactionIndex  hexutil.Uint
+	DecryptionStatus  hexutil.Uint64
+	BatchIndex        hexutil.Uint64
 }
 
 // receiptRLP is the consensus encoding of a receipt.
@@ -88,6 +102,7 @@ type receiptRLP struct {
 	CumulativeGasUsed uint64
 	Bloom             Bloom
 	Logs              []*Log
+	DecryptionStatus  DecryptionStatus `rlp:"optional"`
 }
 
 // storedReceiptRLP is the storage encoding of a receipt.
@@ -97,6 +112,15 @@ type storedReceiptRLP struct {
 	Logs              []*LogForStorage
 }
 
+// shutterStoredReceiptRLP is the storage encoding of a Shutter transaction
+// receipt, which additionally holds the decryption status.
+type shutterStoredReceiptRLP struct {
+	PostStateOrStatus []byte
+	CumulativeGasUsed uint64
+	Logs              []*LogForStorage
+	DecryptionStatus  DecryptionStatus
+}
+
 // v4StoredReceiptRLP is the storage encoding of a receipt used in database version 4.
 type v4StoredReceiptRLP struct {
 	PostStateOrStatus []byte
@@ -137,7 +161,7 @@ func NewReceipt(root []byte, failed bool, cumulativeGasUsed uint64) *Receipt {
 // EncodeRLP implements rlp.Encoder, and flattens the consensus fields of a receipt
 // into an RLP stream. If no post state is present, byzantium fork is assumed.
 func (r *Receipt) EncodeRLP(w io.Writer) error {
-	data := &receiptRLP{r.statusEncoding(), r.CumulativeGasUsed, r.Bloom, r.Logs}
+	data := r.consensusEncoding()
 	if r.Type == LegacyTxType {
 		return rlp.Encode(w, data)
 	}
@@ -176,7 +200,7 @@ func (r *Receipt) DecodeRLP(s *rlp.Stream) error {
 			return errEmptyTypedReceipt
 		}
 		r.Type = b[0]
-		if r.Type == AccessListTxType || r.Type == DynamicFeeTxType || r.Type == ShutterTxType || r.Type == BatchTxType {
+		if isTypedTxRegistered(r.Type) {
 			var dec receiptRLP
 			if err := rlp.DecodeBytes(b[1:], &dec); err != nil {
 				return err
@@ -190,10 +214,29 @@ func (r *Receipt) DecodeRLP(s *rlp.Stream) error {
 }
 
 func (r *Receipt) setFromRLP(data receiptRLP) error {
+	if data.DecryptionStatus != DecryptionStatusNone && r.Type != ShutterTxType {
+		return errUnexpectedDecryptionStatus
+	}
 	r.CumulativeGasUsed, r.Bloom, r.Logs = data.CumulativeGasUsed, data.Bloom, data.Logs
+	r.DecryptionStatus = data.DecryptionStatus
 	return r.setStatus(data.PostStateOrStatus)
 }
 
+// consensusEncoding returns the consensus fields of the receipt. The decryption
+// status is only included for receipts of Shutter transactions.
+func (r *Receipt) consensusEncoding() *receiptRLP {
+	data := &receiptRLP{
+		PostStateOrStatus: r.statusEncoding(),
+		CumulativeGasUsed: r.CumulativeGasUsed,
+		Bloom:             r.Bloom,
+		Logs:              r.Logs,
+	}
+	if r.Type == ShutterTxType {
+		data.DecryptionStatus = r.DecryptionStatus
+	}
+	return data
+}
+
 func (r *Receipt) setStatus(postStateOrStatus []byte) error {
 	switch {
 	case bytes.Equal(postStateOrStatus, receiptStatusSuccessfulRLP):
@@ -236,15 +279,23 @@ type ReceiptForStorage Receipt
 // EncodeRLP implements rlp.Encoder, and flattens all content fields of a receipt
 // into an RLP stream.
 func (r *ReceiptForStorage) EncodeRLP(w io.Writer) error {
-	enc := &storedReceiptRLP{
-		PostStateOrStatus: (*Receipt)(r).statusEncoding(),
-		CumulativeGasUsed: r.CumulativeGasUsed,
-		Logs:              make([]*LogForStorage, len(r.Logs)),
-	}
+	logs := make([]*LogForStorage, len(r.Logs))
 	for i, log := range r.Logs {
-		enc.Logs[i] = (*LogForStorage)(log)
+		logs[i] = (*LogForStorage)(log)
+	}
+	if r.Type == ShutterTxType {
+		return rlp.Encode(w, &shutterStoredReceiptRLP{
+			PostStateOrStatus: (*Receipt)(r).statusEncoding(),
+			CumulativeGasUsed: r.CumulativeGasUsed,
+			Logs:              logs,
+			DecryptionStatus:  r.DecryptionStatus,
+		})
 	}
-	return rlp.Encode(w, enc)
+	return rlp.Encode(w, &storedReceiptRLP{
+		PostStateOrStatus: (*Receipt)(r).statusEncoding(),
+		CumulativeGasUsed: r.CumulativeGasUsed,
+		Logs:              logs,
+	})
 }
 
 // DecodeRLP implements rlp.Decoder, and loads both consensus and implementation
@@ -261,6 +312,9 @@ func (r *ReceiptForStorage) DecodeRLP(s *rlp.Stream) error {
 	if err := decodeStoredReceiptRLP(r, blob); err == nil {
 		return nil
 	}
+	if err := decodeShutterStoredReceiptRLP(r, blob); err == nil {
+		return nil
+	}
 	if err := decodeV3StoredReceiptRLP(r, blob); err == nil {
 		return nil
 	}
@@ -285,6 +339,25 @@ func decodeStoredReceiptRLP(r *ReceiptForStorage, blob []byte) error {
 	return nil
 }
 
+func decodeShutterStoredReceiptRLP(r *ReceiptForStorage, blob []byte) error {
+	var stored shutterStoredReceiptRLP
+	if err := rlp.DecodeBytes(blob, &stored); err != nil {
+		return err
+	}
+	if err := (*Receipt)(r).setStatus(stored.PostStateOrStatus); err != nil {
+		return err
+	}
+	r.CumulativeGasUsed = stored.CumulativeGasUsed
+	r.DecryptionStatus = stored.DecryptionStatus
+	r.Logs = make([]*Log, len(stored.Logs))
+	for i, log := range stored.Logs {
+		r.Logs[i] = (*Log)(log)
+	}
+	r.Bloom = CreateBloom(Receipts{(*Receipt)(r)})
+
+	return nil
+}
+
 func decodeV4StoredReceiptRLP(r *ReceiptForStorage, blob []byte) error {
 	var stored v4StoredReceiptRLP
 	if err := rlp.DecodeBytes(blob, &stored); err != nil {
@@ -335,21 +408,12 @@ func (rs Receipts) Len() int { return len(rs) }
 // EncodeIndex encodes the i'th receipt to w.
 func (rs Receipts) EncodeIndex(i int, w *bytes.Buffer) {
 	r := rs[i]
-	data := &receiptRLP{r.statusEncoding(), r.CumulativeGasUsed, r.Bloom, r.Logs}
-	switch r.Type {
-	case LegacyTxType:
-		rlp.Encode(w, data)
-	case AccessListTxType:
-		w.WriteByte(AccessListTxType)
-		rlp.Encode(w, data)
-	case DynamicFeeTxType:
-		w.WriteByte(DynamicFeeTxType)
-		rlp.Encode(w, data)
-	case ShutterTxType:
-		w.WriteByte(ShutterTxType)
+	data := r.consensusEncoding()
+	switch {
+	case r.Type == LegacyTxType:
 		rlp.Encode(w, data)
-	case BatchTxType:
-		w.WriteByte(BatchTxType)
+	case isTypedTxRegistered(r.Type):
+		w.WriteByte(r.Type)
 		rlp.Encode(w, data)
 	default:
 		// For unsupported types, write nothing. Since this is for
@@ -360,7 +424,7 @@ func (rs Receipts) EncodeIndex(i int, w *bytes.Buffer) {
 
 // DeriveFields fills the receipts with their computed fields based on consensus
 // data and contextual infos like containing block and transactions.
-func (r Receipts) DeriveFields(config *params.ChainConfig, hash common.Hash, number uint64, txs Transactions) error {
+func (r Receipts) DeriveFields(config *ShutterChainConfig, hash common.Hash, number uint64, txs Transactions) error {
 	signer := MakeSigner(config, new(big.Int).SetUint64(number))
 
 	logIndex := uint(0)
@@ -377,10 +441,21 @@ func (r Receipts) DeriveFields(config *params.ChainConfig, hash common.Hash, num
 		r[i].BlockNumber = new(big.Int).SetUint64(number)
 		r[i].TransactionIndex = uint(i)
 
+		// The Shutter fields are derived from the transaction
+		r[i].BatchIndex = txs[i].BatchIndex()
+		r[i].PayloadHash = nil
+		if inner, ok := txs[i].inner.(*ShutterTx); ok && inner.Payload != nil {
+			payloadHash := rlpHash(inner.Payload)
+			r[i].PayloadHash = &payloadHash
+		}
+
 		// The contract address can be derived from the transaction itself
-		if txs[i].To() == nil {
+		if isContractCreation(txs[i]) {
 			// Deriving the signer is expensive, only do if it's actually needed
-			from, _ := Sender(signer, txs[i])
+			from, err := Sender(signer, txs[i])
+			if err != nil {
+				return fmt.Errorf("failed to derive sender of transaction %d: %w", i, err)
+			}
 			r[i].ContractAddress = crypto.CreateAddress(from, txs[i].Nonce())
 		}
 		// The used gas can be calculated based on previous r
@@ -401,3 +476,17 @@ func (r Receipts) DeriveFields(config *params.ChainConfig, hash common.Hash, num
 	}
 	return nil
 }
+
+// isContractCreation returns whether the transaction creates a contract. The
+// recipient of a Shutter transaction is only known once it is decrypted, and
+// batch transactions never create contracts.
+func isContractCreation(tx *Transaction) bool {
+	switch inner := tx.inner.(type) {
+	case *ShutterTx:
+		return inner.Payload != nil && inner.Payload.To == nil
+	case *BatchTx:
+		return false
+	default:
+		return tx.To() == nil
+	}
+}
@@ -0,0 +1,253 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// testShutterConfig activates all forks at genesis on the test chain.
+var testShutterConfig = func() *ShutterChainConfig {
+	config := *params.AllEthashProtocolChanges
+	config.ChainID = testChainID
+	return &ShutterChainConfig{ChainConfig: &config, ShutterBlock: big.NewInt(0)}
+}()
+
+// newTestReceipt returns a successful receipt with one log.
+func newTestReceipt(typ uint8, status DecryptionStatus) *Receipt {
+	r := &Receipt{
+		Type:              typ,
+		Status:            ReceiptStatusSuccessful,
+		CumulativeGasUsed: 50000,
+		Logs: []*Log{{
+			Address: testRecipient,
+			Topics:  []common.Hash{common.HexToHash("0x01")},
+			Data:    []byte{0x02},
+		}},
+		DecryptionStatus: status,
+	}
+	r.Bloom = CreateBloom(Receipts{r})
+	return r
+}
+
+// rlpListLen returns the number of elements of the RLP list.
+func rlpListLen(t *testing.T, enc []byte) int {
+	t.Helper()
+	content, _, err := rlp.SplitList(enc)
+	if err != nil {
+		t.Fatalf("invalid list: %v", err)
+	}
+	n, err := rlp.CountValues(content)
+	if err != nil {
+		t.Fatalf("invalid list: %v", err)
+	}
+	return n
+}
+
+func TestShutterReceiptConsensusRoundTrip(t *testing.T) {
+	for _, status := range []DecryptionStatus{DecryptionStatusNone, DecryptionStatusDecrypted, DecryptionStatusDecryptionFailed, DecryptionStatusWrongBatchIndex} {
+		r := newTestReceipt(ShutterTxType, status)
+		enc, err := rlp.EncodeToBytes(r)
+		if err != nil {
+			t.Fatalf("status %v: failed to encode receipt: %v", status, err)
+		}
+		// The decryption status is only encoded if it is set.
+		var typed []byte
+		if err := rlp.DecodeBytes(enc, &typed); err != nil || typed[0] != ShutterTxType {
+			t.Fatalf("status %v: invalid typed receipt envelope: %x", status, enc)
+		}
+		want := 5
+		if status == DecryptionStatusNone {
+			want = 4
+		}
+		if n := rlpListLen(t, typed[1:]); n != want {
+			t.Errorf("status %v: wrong number of fields: have %d, want %d", status, n, want)
+		}
+		var dec Receipt
+		if err := rlp.DecodeBytes(enc, &dec); err != nil {
+			t.Fatalf("status %v: failed to decode receipt: %v", status, err)
+		}
+		if dec.Type != ShutterTxType || dec.DecryptionStatus != status || dec.Status != r.Status || dec.CumulativeGasUsed != r.CumulativeGasUsed || dec.Bloom != r.Bloom || len(dec.Logs) != 1 {
+			t.Errorf("status %v: wrong decoded receipt: %+v", status, dec)
+		}
+	}
+}
+
+func TestReceiptDecryptionStatusOfOtherTypes(t *testing.T) {
+	// The decryption status of receipts of other types is not encoded.
+	for _, typ := range []uint8{LegacyTxType, DynamicFeeTxType, BatchTxType} {
+		r := newTestReceipt(typ, DecryptionStatusDecrypted)
+		enc, err := rlp.EncodeToBytes(r)
+		if err != nil {
+			t.Fatalf("type %#x: failed to encode receipt: %v", typ, err)
+		}
+		var dec Receipt
+		if err := rlp.DecodeBytes(enc, &dec); err != nil {
+			t.Fatalf("type %#x: failed to decode receipt: %v", typ, err)
+		}
+		if dec.DecryptionStatus != DecryptionStatusNone {
+			t.Errorf("type %#x: decryption status encoded", typ)
+		}
+	}
+	// Decoding one is rejected.
+	data, _ := rlp.EncodeToBytes(&receiptRLP{PostStateOrStatus: receiptStatusSuccessfulRLP, Logs: []*Log{}, DecryptionStatus: DecryptionStatusDecrypted})
+	enc, _ := rlp.EncodeToBytes(append([]byte{DynamicFeeTxType}, data...))
+	if err := rlp.DecodeBytes(enc, new(Receipt)); err != errUnexpectedDecryptionStatus {
+		t.Errorf("wrong error: have %v, want %v", err, errUnexpectedDecryptionStatus)
+	}
+}
+
+func TestReceiptEncodingUnchanged(t *testing.T) {
+	for _, typ := range []uint8{LegacyTxType, AccessListTxType, DynamicFeeTxType} {
+		for _, postState := range [][]byte{nil, common.HexToHash("0xabcd").Bytes()} {
+			r := newTestReceipt(typ, DecryptionStatusNone)
+			r.PostState = postState
+			upstream := &gethtypes.Receipt{
+				Type:              typ,
+				PostState:         postState,
+				Status:            r.Status,
+				CumulativeGasUsed: r.CumulativeGasUsed,
+				Bloom:             gethtypes.Bloom(r.Bloom),
+				Logs:              []*gethtypes.Log{{Address: r.Logs[0].Address, Topics: r.Logs[0].Topics, Data: r.Logs[0].Data}},
+			}
+			have, _ := rlp.EncodeToBytes(r)
+			want, _ := rlp.EncodeToBytes(upstream)
+			if !bytes.Equal(have, want) {
+				t.Errorf("type %#x: wrong consensus encoding: have %x, want %x", typ, have, want)
+			}
+			var haveBuf, wantBuf bytes.Buffer
+			Receipts{r}.EncodeIndex(0, &haveBuf)
+			gethtypes.Receipts{upstream}.EncodeIndex(0, &wantBuf)
+			if !bytes.Equal(haveBuf.Bytes(), wantBuf.Bytes()) {
+				t.Errorf("type %#x: wrong derive encoding: have %x, want %x", typ, haveBuf.Bytes(), wantBuf.Bytes())
+			}
+			have, _ = rlp.EncodeToBytes((*ReceiptForStorage)(r))
+			want, _ = rlp.EncodeToBytes((*gethtypes.ReceiptForStorage)(upstream))
+			if !bytes.Equal(have, want) {
+				t.Errorf("type %#x: wrong storage encoding: have %x, want %x", typ, have, want)
+			}
+		}
+	}
+}
+
+func TestShutterReceiptStorageRoundTrip(t *testing.T) {
+	for _, status := range []DecryptionStatus{DecryptionStatusNone, DecryptionStatusDecrypted, DecryptionStatusDecodeFailed, DecryptionStatusDecryptionFailed} {
+		r := newTestReceipt(ShutterTxType, status)
+		enc, err := rlp.EncodeToBytes((*ReceiptForStorage)(r))
+		if err != nil {
+			t.Fatalf("status %v: failed to encode receipt: %v", status, err)
+		}
+		if n := rlpListLen(t, enc); n != 4 {
+			t.Errorf("status %v: wrong number of stored fields: have %d, want 4", status, n)
+		}
+		var dec ReceiptForStorage
+		if err := rlp.DecodeBytes(enc, &dec); err != nil {
+			t.Fatalf("status %v: failed to decode receipt: %v", status, err)
+		}
+		if dec.DecryptionStatus != status || dec.Status != r.Status || dec.CumulativeGasUsed != r.CumulativeGasUsed || dec.Bloom != r.Bloom || len(dec.Logs) != 1 {
+			t.Errorf("status %v: wrong decoded receipt: %+v", status, dec)
+		}
+	}
+}
+
+func TestReceiptDeriveShutterFields(t *testing.T) {
+	signer := NewShutterSigner(testChainID)
+	encrypted := newTestShutterTx(t, testShutterPay, 5, 0)
+	decrypted, err := encrypted.Decrypt(AESTestScheme{}.DecryptionKey(testEonKey, 5), AESTestScheme{})
+	if err != nil {
+		t.Fatalf("failed to decrypt: %v", err)
+	}
+	txs := Transactions{
+		MustSignNewTx(testKey, signer, &BatchTx{ChainID: testChainID, BatchIndex: 5, Timestamp: big.NewInt(0)}),
+		MustSignNewTx(testKey, signer, &LegacyTx{Nonce: 1, GasPrice: big.NewInt(1), Gas: 21000, To: &testRecipient, Value: big.NewInt(0)}),
+		encrypted,
+		decrypted,
+	}
+	stale := common.HexToHash("0x01")
+	receipts := make(Receipts, len(txs))
+	for i := range receipts {
+		receipts[i] = &Receipt{CumulativeGasUsed: uint64(i+1) * 21000, BatchIndex: 9, PayloadHash: &stale}
+	}
+	if err := receipts.DeriveFields(testShutterConfig, common.Hash{0x01}, 1, txs); err != nil {
+		t.Fatalf("failed to derive fields: %v", err)
+	}
+	payloadHash := rlpHash(testShutterPay)
+	tests := []struct {
+		batchIndex  uint64
+		payloadHash *common.Hash
+	}{
+		{5, nil},
+		{0, nil},
+		{5, nil},
+		{5, &payloadHash},
+	}
+	for i, tt := range tests {
+		r := receipts[i]
+		if r.BatchIndex != tt.batchIndex {
+			t.Errorf("receipt %d: wrong batch index: have %d, want %d", i, r.BatchIndex, tt.batchIndex)
+		}
+		if (r.PayloadHash == nil) != (tt.payloadHash == nil) || r.PayloadHash != nil && *r.PayloadHash != *tt.payloadHash {
+			t.Errorf("receipt %d: wrong payload hash: have %v, want %v", i, r.PayloadHash, tt.payloadHash)
+		}
+		if r.Type != txs[i].Type() || r.TxHash != txs[i].Hash() || r.GasUsed != 21000 {
+			t.Errorf("receipt %d: wrong derived fields: %+v", i, r)
+		}
+	}
+}
+
+func TestReceiptDeriveContractAddress(t *testing.T) {
+	signer := NewShutterSigner(testChainID)
+	creation := &ShutterPayload{Data: []byte{0x60, 0x00}, Value: big.NewInt(0)}
+	encrypted := newTestShutterTx(t, creation, 5, 2)
+	decrypted, err := encrypted.Decrypt(AESTestScheme{}.DecryptionKey(testEonKey, 5), AESTestScheme{})
+	if err != nil {
+		t.Fatalf("failed to decrypt: %v", err)
+	}
+	call, err := newTestShutterTx(t, testShutterPay, 5, 3).Decrypt(AESTestScheme{}.DecryptionKey(testEonKey, 5), AESTestScheme{})
+	if err != nil {
+		t.Fatalf("failed to decrypt: %v", err)
+	}
+	tests := []struct {
+		tx   *Transaction
+		want common.Address
+	}{
+		{MustSignNewTx(testKey, signer, &LegacyTx{Nonce: 0, GasPrice: big.NewInt(1), Gas: 60000, Value: big.NewInt(0)}), crypto.CreateAddress(testAddr, 0)},
+		{MustSignNewTx(testKey, signer, &DynamicFeeTx{ChainID: testChainID, Nonce: 1, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(1), Gas: 21000, To: &testRecipient, Value: big.NewInt(0)}), common.Address{}},
+		// The recipient of an encrypted Shutter transaction is unknown.
+		{encrypted, common.Address{}},
+		{decrypted, crypto.CreateAddress(testAddr, 2)},
+		{call, common.Address{}},
+		{MustSignNewTx(testKey, signer, &BatchTx{ChainID: testChainID, BatchIndex: 5, Timestamp: big.NewInt(0)}), common.Address{}},
+	}
+	for i, tt := range tests {
+		receipts := Receipts{{CumulativeGasUsed: 21000}}
+		if err := receipts.DeriveFields(testShutterConfig, common.Hash{0x01}, 1, Transactions{tt.tx}); err != nil {
+			t.Fatalf("test %d: failed to derive fields: %v", i, err)
+		}
+		if receipts[0].ContractAddress != tt.want {
+			t.Errorf("test %d: wrong contract address: have %v, want %v", i, receipts[0].ContractAddress, tt.want)
+		}
+	}
+}
+
+func TestReceiptDeriveContractAddressSenderError(t *testing.T) {
+	other := big.NewInt(2)
+	txs := Transactions{
+		MustSignNewTx(testKey, NewShutterSigner(testChainID), &LegacyTx{Nonce: 0, GasPrice: big.NewInt(1), Gas: 21000, To: &testRecipient, Value: big.NewInt(0)}),
+		// A contract creation signed for another chain.
+		MustSignNewTx(testKey, NewShutterSigner(other), &DynamicFeeTx{ChainID: other, Nonce: 1, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(1), Gas: 60000, Value: big.NewInt(0)}),
+	}
+	receipts := Receipts{{CumulativeGasUsed: 21000}, {CumulativeGasUsed: 42000}}
+	err := receipts.DeriveFields(testShutterConfig, common.Hash{0x01}, 1, txs)
+	if !errors.Is(err, ErrInvalidChainId) || !strings.Contains(err.Error(), "transaction 1") {
+		t.Errorf("wrong error: have %v, want %v for transaction 1", err, ErrInvalidChainId)
+	}
+}
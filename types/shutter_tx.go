@@ -22,6 +22,9 @@ type ShutterTx struct {
 	// and thus hashing
 	Payload *ShutterPayload `rlp:"-"`
 
+	// Outcome of the decryption, also ignored in rlp encoding
+	DecryptionStatus DecryptionStatus `rlp:"-"`
+
 	// Signature values
 	V *big.Int `json:"v" gencodec:"required"`
 	R *big.Int `json:"r" gencodec:"required"`
@@ -41,6 +44,7 @@ func (tx *ShutterTx) copy() TxInner {
 		GasFeeCap:        new(big.Int),
 		BatchIndex:       tx.BatchIndex,
 		L1BlockNumber:    tx.L1BlockNumber,
+		DecryptionStatus: tx.DecryptionStatus,
 		V:                new(big.Int),
 		R:                new(big.Int),
 		S:                new(big.Int),
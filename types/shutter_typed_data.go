@@ -0,0 +1,158 @@
+package types
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// EIP-712 domain of Shutter transactions.
+const (
+	ShutterTypedDataName    = "Shutter"
+	ShutterTypedDataVersion = "1"
+)
+
+var (
+	eip712DomainType = []TypedDataField{
+		{Name: "name", Type: "string"},
+		{Name: "version", Type: "string"},
+		{Name: "chainId", Type: "uint256"},
+	}
+	shutterTxType = []TypedDataField{
+		{Name: "chainId", Type: "uint256"},
+		{Name: "batchIndex", Type: "uint64"},
+		{Name: "nonce", Type: "uint64"},
+		{Name: "maxPriorityFeePerGas", Type: "uint256"},
+		{Name: "maxFeePerGas", Type: "uint256"},
+		{Name: "gas", Type: "uint64"},
+		{Name: "encryptedPayloadHash", Type: "bytes32"},
+		{Name: "l1BlockNumber", Type: "uint64"},
+	}
+
+	eip712DomainTypeHash = crypto.Keccak256Hash([]byte(encodeTypedDataType("EIP712Domain", eip712DomainType)))
+	shutterTxTypeHash    = crypto.Keccak256Hash([]byte(encodeTypedDataType("ShutterTx", shutterTxType)))
+)
+
+// TypedDataField is a field of an EIP-712 struct type.
+type TypedDataField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// TypedDataDomain is the EIP-712 domain of Shutter transactions.
+type TypedDataDomain struct {
+	Name    string                `json:"name"`
+	Version string                `json:"version"`
+	ChainId *math.HexOrDecimal256 `json:"chainId"`
+}
+
+// TypedData is the EIP-712 representation of a Shutter transaction. Its JSON
+// encoding can be passed to eth_signTypedData_v4, so that wallets can display
+// what is being signed.
+type TypedData struct {
+	Types       map[string][]TypedDataField `json:"types"`
+	PrimaryType string                      `json:"primaryType"`
+	Domain      TypedDataDomain             `json:"domain"`
+	Message     map[string]interface{}      `json:"message"`
+}
+
+// ShutterTxTypedData returns the EIP-712 typed data of a Shutter transaction
+// for the given chain ID.
+func ShutterTxTypedData(tx *Transaction, chainID *big.Int) (*TypedData, error) {
+	if tx.Type() != ShutterTxType {
+		return nil, ErrInvalidTxType
+	}
+	return &TypedData{
+		Types: map[string][]TypedDataField{
+			"EIP712Domain": eip712DomainType,
+			"ShutterTx":    shutterTxType,
+		},
+		PrimaryType: "ShutterTx",
+		Domain: TypedDataDomain{
+			Name:    ShutterTypedDataName,
+			Version: ShutterTypedDataVersion,
+			ChainId: (*math.HexOrDecimal256)(new(big.Int).Set(chainID)),
+		},
+		Message: map[string]interface{}{
+			"chainId":              chainID.String(),
+			"batchIndex":           new(big.Int).SetUint64(tx.BatchIndex()).String(),
+			"nonce":                new(big.Int).SetUint64(tx.Nonce()).String(),
+			"maxPriorityFeePerGas": tx.GasTipCap().String(),
+			"maxFeePerGas":         tx.GasFeeCap().String(),
+			"gas":                  new(big.Int).SetUint64(tx.Gas()).String(),
+			"encryptedPayloadHash": hexutil.Encode(crypto.Keccak256(tx.EncryptedPayload())),
+			"l1BlockNumber":        new(big.Int).SetUint64(tx.L1BlockNumber()).String(),
+		},
+	}, nil
+}
+
+type eip712ShutterSigner struct{ shutterSigner }
+
+// NewEIP712ShutterSigner returns a signer that accepts the same transactions as
+// the signer returned by NewShutterSignerV2, but expects Shutter transactions to
+// be signed as EIP-712 typed data, see ShutterTxTypedData.
+func NewEIP712ShutterSigner(chainId *big.Int) Signer {
+	return eip712ShutterSigner{shutterSigner{londonSigner{eip2930Signer{NewEIP155Signer(chainId)}}, ShutterSigningV2}}
+}
+
+func (s eip712ShutterSigner) Sender(tx *Transaction) (common.Address, error) {
+	if tx.Type() != ShutterTxType {
+		return s.shutterSigner.Sender(tx)
+	}
+	V, R, S := tx.RawSignatureValues()
+	// Shutter txs are defined to use 0 and 1 as their recovery
+	// id, add 27 to become equivalent to unprotected Homestead signatures.
+	V = new(big.Int).Add(V, big.NewInt(27))
+	if tx.ChainId().Cmp(s.chainId) != 0 {
+		return common.Address{}, ErrInvalidChainId
+	}
+	return recoverPlain(s.Hash(tx), R, S, V, true)
+}
+
+func (s eip712ShutterSigner) Equal(s2 Signer) bool {
+	x, ok := s2.(eip712ShutterSigner)
+	return ok && x.chainId.Cmp(s.chainId) == 0
+}
+
+// Hash returns the hash to be signed by the sender. For Shutter transactions
+// this is the EIP-712 hash of the typed data.
+// It does not uniquely identify the transaction.
+func (s eip712ShutterSigner) Hash(tx *Transaction) common.Hash {
+	if tx.Type() != ShutterTxType {
+		return s.shutterSigner.Hash(tx)
+	}
+	domainSeparator := crypto.Keccak256(
+		eip712DomainTypeHash[:],
+		crypto.Keccak256([]byte(ShutterTypedDataName)),
+		crypto.Keccak256([]byte(ShutterTypedDataVersion)),
+		math.U256Bytes(new(big.Int).Set(s.chainId)),
+	)
+	structHash := crypto.Keccak256(
+		shutterTxTypeHash[:],
+		math.U256Bytes(new(big.Int).Set(s.chainId)),
+		math.U256Bytes(new(big.Int).SetUint64(tx.BatchIndex())),
+		math.U256Bytes(new(big.Int).SetUint64(tx.Nonce())),
+		math.U256Bytes(tx.GasTipCap()),
+		math.U256Bytes(tx.GasFeeCap()),
+		math.U256Bytes(new(big.Int).SetUint64(tx.Gas())),
+		crypto.Keccak256(tx.EncryptedPayload()),
+		math.U256Bytes(new(big.Int).SetUint64(tx.L1BlockNumber())),
+	)
+	return crypto.Keccak256Hash([]byte("\x19\x01"), domainSeparator, structHash)
+}
+
+// encodeTypedDataType returns the EIP-712 type encoding of a struct type
+// without references to other struct types.
+func encodeTypedDataType(name string, fields []TypedDataField) string {
+	enc := name + "("
+	for i, f := range fields {
+		if i > 0 {
+			enc += ","
+		}
+		enc += f.Type + " " + f.Name
+	}
+	return enc + ")"
+}
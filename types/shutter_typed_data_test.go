@@ -0,0 +1,48 @@
+package types
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestEIP712ShutterSignerHash(t *testing.T) {
+	// Computed with TypedData.HashStruct of go-ethereum's signer/core for the
+	// JSON encoding of ShutterTxTypedData(signingHashTestTx, 1).
+	want := common.HexToHash("0x846e5c0201dae8f1abd786354a1010ce6a07b5e0a2d597eed5325d3273306b9c")
+	if have := NewEIP712ShutterSigner(big.NewInt(1)).Hash(signingHashTestTx); have != want {
+		t.Errorf("wrong signing hash: have %v, want %v", have, want)
+	}
+
+	data, err := ShutterTxTypedData(signingHashTestTx, big.NewInt(1))
+	if err != nil {
+		t.Fatalf("failed to create typed data: %v", err)
+	}
+	if data.PrimaryType != "ShutterTx" || data.Message["batchIndex"] != "42" || data.Message["l1BlockNumber"] != "1234" {
+		t.Errorf("wrong typed data: %+v", data)
+	}
+	if _, err := ShutterTxTypedData(NewTx(&DynamicFeeTx{ChainID: big.NewInt(1)}), big.NewInt(1)); err != ErrInvalidTxType {
+		t.Errorf("wrong error for non-Shutter tx: have %v, want %v", err, ErrInvalidTxType)
+	}
+}
+
+func TestEIP712ShutterSignerRoundTrip(t *testing.T) {
+	signer := NewEIP712ShutterSigner(testChainID)
+	tx, err := SignNewEncryptedShutterTx(testKey, signer, testShutterPay, testEonKey, 5, 7, 0, big.NewInt(1), big.NewInt(10), 100000, AESTestScheme{})
+	if err != nil {
+		t.Fatalf("failed to sign tx: %v", err)
+	}
+	if from, err := Sender(signer, tx); err != nil || from != testAddr {
+		t.Errorf("wrong sender: have %v (%v), want %v", from, err, testAddr)
+	}
+	// The typed data signature is not a valid ShutterSigningV2 signature.
+	if from, err := Sender(NewShutterSignerV2(testChainID), tx); err == nil && from == testAddr {
+		t.Errorf("typed data signature accepted by ShutterSigningV2 signer")
+	}
+	// Other transaction types are signed like with the ShutterSigningV2 signer.
+	plain := MustSignNewTx(testKey, signer, &DynamicFeeTx{ChainID: testChainID, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(1), Gas: 21000, To: &testRecipient, Value: big.NewInt(0)})
+	if from, err := Sender(NewShutterSignerV2(testChainID), plain); err != nil || from != testAddr {
+		t.Errorf("wrong sender of plaintext tx: have %v (%v), want %v", from, err, testAddr)
+	}
+}
@@ -0,0 +1,255 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"bytes"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// StackTrie is a TrieHasher that computes the root hash of a Merkle-Patricia
+// trie from keys inserted in increasing order, which is the order DeriveSha
+// inserts them in. Once it determines that a subtree will no longer be inserted
+// into, it hashes it and frees up the memory it uses, so it only holds the path
+// to the last inserted key. It is adapted from the StackTrie of go-ethereum,
+// without the database.
+//
+// Use MemoryTrie to insert keys in any order or to create proofs.
+type StackTrie struct {
+	root    *stackNode
+	lastKey []byte
+}
+
+// NewStackTrie allocates and initializes an empty trie.
+func NewStackTrie() *StackTrie {
+	return &StackTrie{root: new(stackNode)}
+}
+
+// Reset removes all inserted pairs.
+func (t *StackTrie) Reset() {
+	t.root = new(stackNode)
+	t.lastKey = nil
+}
+
+// Update inserts the key/value pair. The value is copied. Update panics if the
+// key is not greater than the previously inserted one, if it is a prefix of it,
+// if the value is empty, since deletion is not supported, or if the trie was
+// already hashed without being reset.
+func (t *StackTrie) Update(key, value []byte) {
+	if len(value) == 0 {
+		panic("stack trie: deletion not supported")
+	}
+	if t.lastKey != nil && bytes.Compare(key, t.lastKey) <= 0 {
+		panic("stack trie: keys not inserted in increasing order")
+	}
+	t.lastKey = common.CopyBytes(key)
+	k := keybytesToHex(key)
+	t.root.insert(k[:len(k)-1], common.CopyBytes(value))
+}
+
+// Hash returns the root hash of the trie. Afterwards, no more keys can be
+// inserted until the trie is reset.
+func (t *StackTrie) Hash() common.Hash {
+	if t.root.typ == stackEmptyNode {
+		return EmptyRootHash
+	}
+	t.root.hash()
+	if len(t.root.val) < 32 {
+		// The root is always referenced by its hash, even if its encoding
+		// would be embedded into a parent.
+		return crypto.Keccak256Hash(t.root.val)
+	}
+	return common.BytesToHash(t.root.val)
+}
+
+// Types of the nodes of a StackTrie.
+const (
+	stackEmptyNode = iota
+	stackBranchNode
+	stackExtNode
+	stackLeafNode
+	stackHashedNode
+)
+
+// stackNode is a node of a StackTrie.
+type stackNode struct {
+	typ       uint8
+	val       []byte         // value of a leaf, or the reference to a hashed node
+	key       []byte         // key nibbles covered by an extension or a leaf
+	keyOffset int            // offset of the key nibbles inside a full key
+	children  [16]*stackNode // children of a branch, or the child of an extension
+}
+
+// newStackLeaf returns a leaf holding the value under the nibbles of the key
+// from keyOffset on.
+func newStackLeaf(keyOffset int, key, value []byte) *stackNode {
+	return &stackNode{
+		typ:       stackLeafNode,
+		val:       value,
+		key:       common.CopyBytes(key[keyOffset:]),
+		keyOffset: keyOffset,
+	}
+}
+
+// diffIndex returns the index at which the key nibbles of the node differ from
+// the ones of the full key.
+func (n *stackNode) diffIndex(key []byte) int {
+	i := 0
+	for i < len(n.key) && n.key[i] == key[n.keyOffset+i] {
+		i++
+	}
+	return i
+}
+
+// insert inserts the pair into the subtree of the node. The key are the nibbles
+// of the full key without terminator.
+func (n *stackNode) insert(key, value []byte) {
+	switch n.typ {
+	case stackEmptyNode:
+		n.typ = stackLeafNode
+		n.key = common.CopyBytes(key[n.keyOffset:])
+		n.val = value
+
+	case stackBranchNode:
+		idx := key[n.keyOffset]
+		// Keys are inserted in order, so the closest elder sibling won't be
+		// inserted into anymore and can be hashed.
+		for i := int(idx) - 1; i >= 0; i-- {
+			if n.children[i] != nil {
+				n.children[i].hash()
+				break
+			}
+		}
+		if n.children[idx] == nil {
+			n.children[idx] = &stackNode{keyOffset: n.keyOffset + 1}
+		}
+		n.children[idx].insert(key, value)
+
+	case stackExtNode:
+		diff := n.diffIndex(key)
+		if diff == len(n.key) {
+			// The key continues below the extension.
+			n.children[0].insert(key, value)
+			return
+		}
+		// The key leaves the extension, so its original part is complete.
+		// Depending on whether the break is at the last nibble of the
+		// extension, it continues with a shorter extension or directly with
+		// the child.
+		var orig *stackNode
+		if diff < len(n.key)-1 {
+			orig = &stackNode{typ: stackExtNode, key: n.key[diff+1:], keyOffset: n.keyOffset + diff + 1}
+			orig.children[0] = n.children[0]
+		} else {
+			orig = n.children[0]
+		}
+		orig.hash()
+		// The differing nibble selects the path in a branch, which replaces
+		// the extension if the break is at its first nibble.
+		branch := n
+		if diff == 0 {
+			n.typ = stackBranchNode
+			n.children[0] = nil
+		} else {
+			branch = &stackNode{typ: stackBranchNode, keyOffset: n.keyOffset + diff}
+			n.children[0] = branch
+		}
+		branch.children[n.key[diff]] = orig
+		branch.children[key[n.keyOffset+diff]] = newStackLeaf(n.keyOffset+diff+1, key, value)
+		n.key = n.key[:diff]
+
+	case stackLeafNode:
+		diff := n.diffIndex(key)
+		if diff == len(n.key) {
+			panic("stack trie: key is a prefix of another key")
+		}
+		// The leaf is split into an optional extension for the common prefix,
+		// a branch selecting the differing nibble and one leaf per key. The
+		// leaf of the original key is complete and hashed right away.
+		branch := n
+		if diff == 0 {
+			n.typ = stackBranchNode
+		} else {
+			n.typ = stackExtNode
+			branch = &stackNode{typ: stackBranchNode, keyOffset: n.keyOffset + diff}
+			n.children[0] = branch
+		}
+		orig := &stackNode{typ: stackLeafNode, val: n.val, key: n.key[diff+1:], keyOffset: n.keyOffset + diff + 1}
+		orig.hash()
+		branch.children[n.key[diff]] = orig
+		branch.children[key[n.keyOffset+diff]] = newStackLeaf(n.keyOffset+diff+1, key, value)
+		n.key = n.key[:diff]
+		n.val = nil
+
+	case stackHashedNode:
+		panic("stack trie: insert into hashed node")
+	}
+}
+
+// hash hashes the subtree of the node and converts the node into a hashed node,
+// whose value is the reference to the node in its parent: the hash of its
+// encoding, or the encoding itself if it is shorter than a hash.
+func (n *stackNode) hash() {
+	var enc []byte
+	switch n.typ {
+	case stackHashedNode:
+		return
+
+	case stackBranchNode:
+		var refs [17]interface{}
+		for i, child := range n.children {
+			if child == nil {
+				refs[i] = []byte(nil)
+				continue
+			}
+			child.hash()
+			refs[i] = child.ref()
+		}
+		refs[16] = []byte(nil)
+		enc, _ = rlp.EncodeToBytes(refs[:])
+
+	case stackExtNode:
+		n.children[0].hash()
+		enc, _ = rlp.EncodeToBytes([]interface{}{hexToCompact(n.key), n.children[0].ref()})
+
+	case stackLeafNode:
+		key := append(common.CopyBytes(n.key), 16)
+		enc, _ = rlp.EncodeToBytes([]interface{}{hexToCompact(key), n.val})
+
+	default:
+		panic("stack trie: hash of empty node")
+	}
+	n.typ = stackHashedNode
+	n.key = nil
+	n.children = [16]*stackNode{}
+	if len(enc) < 32 {
+		n.val = enc
+	} else {
+		n.val = crypto.Keccak256(enc)
+	}
+}
+
+// ref returns how a hashed node is referenced by its parent.
+func (n *stackNode) ref() interface{} {
+	if len(n.val) < 32 {
+		return rlp.RawValue(n.val)
+	}
+	return n.val
+}
@@ -0,0 +1,117 @@
+package types
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestStackTrieUpstreamRoots(t *testing.T) {
+	trie := NewStackTrie()
+	for _, tt := range memoryTrieTestRoots {
+		// The trie is reused, DeriveSha resets it.
+		if root := DeriveSha(newTrieTestTxs(tt.n), trie); root != tt.root {
+			t.Errorf("%d txs: wrong root: have %v, want %v", tt.n, root, tt.root)
+		}
+		if root := trie.Hash(); root != tt.root {
+			t.Errorf("%d txs: wrong root of second Hash: have %v, want %v", tt.n, root, tt.root)
+		}
+	}
+}
+
+func TestStackTrieRandomKeys(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		// Keys of equal length are never prefixes of each other. Short keys
+		// and values produce embedded nodes.
+		keyLen, n := 1+rng.Intn(4), 1+rng.Intn(300)
+		pairs := make(map[string][]byte)
+		for len(pairs) < n && len(pairs) < 1<<(8*keyLen) {
+			key := make([]byte, keyLen)
+			rng.Read(key)
+			value := make([]byte, 1+rng.Intn(40))
+			rng.Read(value)
+			pairs[string(key)] = value
+		}
+		keys := make([]string, 0, len(pairs))
+		for key := range pairs {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		stack, memory := NewStackTrie(), NewMemoryTrie()
+		for _, key := range keys {
+			stack.Update([]byte(key), pairs[key])
+			memory.Update([]byte(key), pairs[key])
+		}
+		if have, want := stack.Hash(), memory.Hash(); have != want {
+			t.Fatalf("test %d: wrong root for %d keys of length %d: have %v, want %v", i, len(keys), keyLen, have, want)
+		}
+	}
+}
+
+func TestStackTrieInvalidUpdates(t *testing.T) {
+	tests := []struct {
+		name string
+		keys [][]byte
+		hash bool // whether the trie is hashed before the last update
+	}{
+		{"decreasing", [][]byte{{0x02}, {0x01}}, false},
+		{"duplicate", [][]byte{{0x01, 0x02}, {0x01, 0x02}}, false},
+		{"prefix", [][]byte{{0x01}, {0x01, 0x02}}, false},
+		{"hashed", [][]byte{{0x01}, {0x02}}, true},
+		{"empty value", [][]byte{{0x01}, nil}, false},
+	}
+	for _, tt := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: no panic", tt.name)
+				}
+			}()
+			trie := NewStackTrie()
+			trie.Update(tt.keys[0], []byte("value"))
+			if tt.hash {
+				trie.Hash()
+			}
+			if tt.keys[1] == nil {
+				trie.Update([]byte{0x02}, nil)
+			} else {
+				trie.Update(tt.keys[1], []byte("value"))
+			}
+		}()
+	}
+
+	// After a reset, keys start over.
+	trie := NewStackTrie()
+	trie.Update([]byte{0x02}, []byte("value"))
+	trie.Hash()
+	trie.Reset()
+	if root := trie.Hash(); root != EmptyRootHash {
+		t.Errorf("wrong root after reset: have %v, want %v", root, EmptyRootHash)
+	}
+	trie.Update([]byte{0x01}, []byte("value"))
+	memory := NewMemoryTrie()
+	memory.Update([]byte{0x01}, []byte("value"))
+	if have, want := trie.Hash(), memory.Hash(); have != want {
+		t.Errorf("wrong root after reset and update: have %v, want %v", have, want)
+	}
+}
+
+func BenchmarkDeriveSha(b *testing.B) {
+	txs := newTrieTestTxs(1000)
+	for _, bench := range []struct {
+		name   string
+		hasher TrieHasher
+	}{
+		{"StackTrie", NewStackTrie()},
+		{"MemoryTrie", NewMemoryTrie()},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				DeriveSha(txs, bench.hasher)
+			}
+		})
+	}
+}
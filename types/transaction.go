@@ -55,9 +55,10 @@ type Transaction struct {
 	time  time.Time // Time first seen locally (spam avoidance)
 
 	// caches
-	hash atomic.Value
-	size atomic.Value
-	from atomic.Value
+	hash  atomic.Value
+	size  atomic.Value
+	from  atomic.Value
+	batch atomic.Value
 }
 
 // NewTx creates a new transaction.
@@ -88,8 +89,6 @@ type TxInner interface {
 
 	rawSignatureValues() (v, r, s *big.Int)
 	setSignatureValues(chainID, v, r, s *big.Int)
-
-	TxInnerExtension
 }
 
 // EncodeRLP implements rlp.Encoder
@@ -182,26 +181,12 @@ func (tx *Transaction) decodeTyped(b []byte) (TxInner, error) {
 	if len(b) == 0 {
 		return nil, errEmptyTypedTx
 	}
-	switch b[0] {
-	case AccessListTxType:
-		var inner AccessListTx
-		err := rlp.DecodeBytes(b[1:], &inner)
-		return &inner, err
-	case DynamicFeeTxType:
-		var inner DynamicFeeTx
-		err := rlp.DecodeBytes(b[1:], &inner)
-		return &inner, err
-	case ShutterTxType:
-		var inner ShutterTx
-		err := rlp.DecodeBytes(b[1:], &inner)
-		return &inner, err
-	case BatchTxType:
-		var inner BatchTx
-		err := rlp.DecodeBytes(b[1:], &inner)
-		return &inner, err
-	default:
+	if !isTypedTxRegistered(b[0]) {
 		return nil, ErrTxTypeNotSupported
 	}
+	inner := txTypeCodecs[b[0]].NewInner()
+	err := rlp.DecodeBytes(b[1:], inner)
+	return inner, err
 }
 
 // setDecoded sets the inner transaction and size after decoding.
@@ -311,23 +296,53 @@ func (tx *Transaction) To() *common.Address {
 }
 
 // EncryptedPayload returns the encrypted payload of a Shutter transaction.
-func (tx *Transaction) EncryptedPayload() []byte { return tx.inner.encryptedPayload() }
+func (tx *Transaction) EncryptedPayload() []byte {
+	if ext := tx.extension(); ext != nil {
+		return ext.encryptedPayload()
+	}
+	return nil
+}
 
 // DecryptionKey returns the decryption key of a decryption key transaction.
-func (tx *Transaction) DecryptionKey() []byte { return tx.inner.decryptionKey() }
+func (tx *Transaction) DecryptionKey() []byte {
+	if ext := tx.extension(); ext != nil {
+		return ext.decryptionKey()
+	}
+	return nil
+}
 
 // BatchIndex returns the batch index (a.k.a sequence number) of a Shutter transaction,
-func (tx *Transaction) BatchIndex() uint64 { return tx.inner.batchIndex() }
+func (tx *Transaction) BatchIndex() uint64 {
+	if ext := tx.extension(); ext != nil {
+		return ext.batchIndex()
+	}
+	return 0
+}
 
 // L1BlockNumber returns the Layer 1 block number used for identifying the
 // collator/keyper config
-func (tx *Transaction) L1BlockNumber() uint64 { return tx.inner.l1BlockNumber() }
+func (tx *Transaction) L1BlockNumber() uint64 {
+	if ext := tx.extension(); ext != nil {
+		return ext.l1BlockNumber()
+	}
+	return 0
+}
 
 // Timestamp returns the timestamp ()
-func (tx *Transaction) Timestamp() *big.Int { return tx.inner.timestamp() }
+func (tx *Transaction) Timestamp() *big.Int {
+	if ext := tx.extension(); ext != nil {
+		return ext.timestamp()
+	}
+	return nil
+}
 
 // Transactions returns the list of RLP-byte serialised ShutterTxs and plaintext txs included in the batch
-func (tx *Transaction) Transactions() [][]byte { return tx.inner.transactions() }
+func (tx *Transaction) Transactions() [][]byte {
+	if ext := tx.extension(); ext != nil {
+		return ext.transactions()
+	}
+	return nil
+}
 
 // Cost returns gas * gasPrice + value.
 func (tx *Transaction) Cost() *big.Int {
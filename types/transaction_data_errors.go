@@ -0,0 +1,129 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// MissingFieldError is returned when a required field of the JSON representation
+// of a transaction is missing.
+type MissingFieldError struct {
+	Field  string // JSON name of the field
+	TxType uint8
+}
+
+func (e *MissingFieldError) Error() string {
+	return fmt.Sprintf("missing required field '%s' for transaction type %#x", e.Field, e.TxType)
+}
+
+// InvalidFieldError is returned when a field of the JSON representation of a
+// transaction has an invalid value or is not allowed for the transaction type.
+type InvalidFieldError struct {
+	Field  string // JSON name of the field
+	TxType uint8
+	Err    error
+}
+
+func (e *InvalidFieldError) Error() string {
+	return fmt.Sprintf("invalid field '%s' for transaction type %#x: %v", e.Field, e.TxType, e.Err)
+}
+
+func (e *InvalidFieldError) Unwrap() error {
+	return e.Err
+}
+
+// SignatureError is returned when the signature values of the JSON
+// representation of a transaction are invalid.
+type SignatureError struct {
+	TxType uint8
+	Err    error // ErrInvalidSig or ErrUnexpectedProtection
+}
+
+func (e *SignatureError) Error() string {
+	return fmt.Sprintf("invalid signature for transaction type %#x: %v", e.TxType, e.Err)
+}
+
+func (e *SignatureError) Unwrap() error {
+	return e.Err
+}
+
+// TransactionDataErrors holds all problems found while decoding the JSON
+// representation of a transaction. errors.Is and errors.As match it if they
+// match any of the contained errors.
+type TransactionDataErrors []error
+
+func (e TransactionDataErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e TransactionDataErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e TransactionDataErrors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// txDataErrors collects the problems of the JSON representation of a
+// transaction of the given type.
+type txDataErrors struct {
+	txType uint8
+	errs   TransactionDataErrors
+}
+
+func (e *txDataErrors) missing(field string) {
+	e.errs = append(e.errs, &MissingFieldError{Field: field, TxType: e.txType})
+}
+
+func (e *txDataErrors) invalid(field string, err error) {
+	e.errs = append(e.errs, &InvalidFieldError{Field: field, TxType: e.txType, Err: err})
+}
+
+// signature returns the signature values of the transaction and records
+// missing and invalid ones.
+func (e *txDataErrors) signature(dec *TransactionData, maybeProtected bool) (v, r, s *big.Int) {
+	if dec.V == nil {
+		e.missing("v")
+	}
+	if dec.R == nil {
+		e.missing("r")
+	}
+	if dec.S == nil {
+		e.missing("s")
+	}
+	if dec.V == nil || dec.R == nil || dec.S == nil {
+		return nil, nil, nil
+	}
+	v, r, s = (*big.Int)(dec.V), (*big.Int)(dec.R), (*big.Int)(dec.S)
+	withSignature := v.Sign() != 0 || r.Sign() != 0 || s.Sign() != 0
+	if withSignature {
+		if err := sanityCheckSignature(v, r, s, maybeProtected); err != nil {
+			e.errs = append(e.errs, &SignatureError{TxType: e.txType, Err: err})
+		}
+	}
+	return v, r, s
+}
+
+// err returns the collected problems, or nil if there are none.
+func (e *txDataErrors) err() error {
+	if len(e.errs) == 0 {
+		return nil
+	}
+	return e.errs
+}
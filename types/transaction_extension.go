@@ -2,6 +2,10 @@ package types
 
 import "math/big"
 
+// TxInnerExtension is implemented by the inner transactions of the Shutter
+// transaction types. It is optional: the accessors of Transaction return zero
+// values for inner transactions that don't implement it, so the upstream types
+// and types registered with RegisterTxType don't need stubs.
 type TxInnerExtension interface {
 	encryptedPayload() []byte
 	decryptionKey() []byte
@@ -11,23 +15,9 @@ type TxInnerExtension interface {
 	transactions() [][]byte
 }
 
-func (tx *DynamicFeeTx) encryptedPayload() []byte { return nil }
-func (tx *DynamicFeeTx) decryptionKey() []byte    { return nil }
-func (tx *DynamicFeeTx) batchIndex() uint64       { return 0 }
-func (tx *DynamicFeeTx) l1BlockNumber() uint64    { return 0 }
-func (tx *DynamicFeeTx) timestamp() *big.Int      { return nil }
-func (tx *DynamicFeeTx) transactions() [][]byte   { return nil }
-
-func (tx *AccessListTx) encryptedPayload() []byte { return nil }
-func (tx *AccessListTx) decryptionKey() []byte    { return nil }
-func (tx *AccessListTx) batchIndex() uint64       { return 0 }
-func (tx *AccessListTx) l1BlockNumber() uint64    { return 0 }
-func (tx *AccessListTx) timestamp() *big.Int      { return nil }
-func (tx *AccessListTx) transactions() [][]byte   { return nil }
-
-func (tx *LegacyTx) encryptedPayload() []byte { return nil }
-func (tx *LegacyTx) decryptionKey() []byte    { return nil }
-func (tx *LegacyTx) batchIndex() uint64       { return 0 }
-func (tx *LegacyTx) l1BlockNumber() uint64    { return 0 }
-func (tx *LegacyTx) timestamp() *big.Int      { return nil }
-func (tx *LegacyTx) transactions() [][]byte   { return nil }
+// extension returns the Shutter fields of the inner transaction, or nil if its
+// type has none.
+func (tx *Transaction) extension() TxInnerExtension {
+	ext, _ := tx.inner.(TxInnerExtension)
+	return ext
+}
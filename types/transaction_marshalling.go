@@ -21,12 +21,30 @@ import (
 	"fmt"
 	"math/big"
 	"reflect"
+	"sort"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/pkg/errors"
 )
 
+var (
+	ErrUnexpectedJSONField = errors.New("unexpected field in transaction")
+	ErrTxHashMismatch      = errors.New("transaction hash mismatch")
+	ErrTxSenderMismatch    = errors.New("transaction sender mismatch")
+)
+
+// commonJSONFields are the fields of the JSON representation shared by all
+// transaction types.
+var commonJSONFields = []string{"type", "hash", "from", "blockHash", "blockNumber", "transactionIndex", "v", "r", "s"}
+
+// Fields of the objects nested in the JSON representation.
+var (
+	accessTupleJSONFields      = []string{"address", "storageKeys"}
+	decryptedPayloadJSONFields = []string{"to", "value", "data"}
+)
+
 type TransactionData struct {
 	Type hexutil.Uint64 `json:"type"`
 
@@ -49,7 +67,9 @@ type TransactionData struct {
 	MaxPriorityFeePerGas *hexutil.Big `json:"maxPriorityFeePerGas,omitempty"`
 
 	// ShutterTx
-	EncryptedPayload *hexutil.Bytes `json:"encryptedPayload,omitempty"`
+	EncryptedPayload *hexutil.Bytes        `json:"encryptedPayload,omitempty"`
+	DecryptedPayload *DecryptedPayloadData `json:"decryptedPayload,omitempty"`
+	DecryptionStatus *hexutil.Uint64       `json:"decryptionStatus,omitempty"`
 
 	// BatchTx
 	DecryptionKey *hexutil.Bytes  `json:"decryptionKey,omitempty"`
@@ -70,28 +90,33 @@ type TransactionData struct {
 	S *hexutil.Big `json:"s"`
 }
 
+// DecryptedPayloadData is the JSON representation of the decrypted payload of a
+// Shutter transaction.
+type DecryptedPayloadData struct {
+	To    *common.Address `json:"to"`
+	Value *hexutil.Big    `json:"value"`
+	Data  *hexutil.Bytes  `json:"data"`
+}
+
+// ValidateRequiredFields checks that the fields with the given Go names are set.
+// Missing fields are reported as MissingFieldError with their JSON names.
 func (td *TransactionData) ValidateRequiredFields(names ...string) (bool, error) {
 	rv := reflect.ValueOf(td)
 	rv = rv.Elem()
 
-	inValidFieldNames := make([]string, 0)
-
+	errs := &txDataErrors{txType: uint8(td.Type)}
 	for _, fieldName := range names {
-		f := rv.FieldByName(fieldName)
-		if (f == reflect.Value{}) {
+		sf, ok := rv.Type().FieldByName(fieldName)
+		if !ok {
 			// field was not found, programming error!
 			return false, errors.Errorf("field '%s' is not defined in type", fieldName)
 		}
-		if !f.IsValid() || f.IsNil() {
-			inValidFieldNames = append(inValidFieldNames, fieldName)
+		if f := rv.FieldByIndex(sf.Index); !f.IsValid() || f.IsNil() {
+			errs.missing(strings.Split(sf.Tag.Get("json"), ",")[0])
 		}
 	}
-	if len(inValidFieldNames) > 0 {
-		errStr := "required fields are nil: "
-		for _, n := range inValidFieldNames {
-			errStr = errStr + fmt.Sprintf(" %s,", n)
-		}
-		return false, errors.New(errStr)
+	if err := errs.err(); err != nil {
+		return false, err
 	}
 	return true, nil
 }
@@ -108,75 +133,9 @@ func (t *Transaction) TransactionData() (enc *TransactionData) {
 	enc.Hash = t.Hash()
 	enc.Type = hexutil.Uint64(t.Type())
 
-	// Other fields are set conditionally depending on tx type.
-	switch tx := t.inner.(type) {
-	case *LegacyTx:
-		enc.Nonce = (*hexutil.Uint64)(&tx.Nonce)
-		enc.Gas = (*hexutil.Uint64)(&tx.Gas)
-		enc.GasPrice = (*hexutil.Big)(tx.GasPrice)
-		enc.Value = (*hexutil.Big)(tx.Value)
-		enc.Input = (*hexutil.Bytes)(&tx.Data)
-		enc.To = t.To()
-		enc.V = (*hexutil.Big)(tx.V)
-		enc.R = (*hexutil.Big)(tx.R)
-		enc.S = (*hexutil.Big)(tx.S)
-	case *AccessListTx:
-		enc.ChainID = (*hexutil.Big)(tx.ChainID)
-		enc.AccessList = &tx.AccessList
-		enc.Nonce = (*hexutil.Uint64)(&tx.Nonce)
-		enc.Gas = (*hexutil.Uint64)(&tx.Gas)
-		enc.GasPrice = (*hexutil.Big)(tx.GasPrice)
-		enc.Value = (*hexutil.Big)(tx.Value)
-		enc.Input = (*hexutil.Bytes)(&tx.Data)
-		enc.To = t.To()
-		enc.V = (*hexutil.Big)(tx.V)
-		enc.R = (*hexutil.Big)(tx.R)
-		enc.S = (*hexutil.Big)(tx.S)
-	case *DynamicFeeTx:
-		enc.ChainID = (*hexutil.Big)(tx.ChainID)
-		enc.AccessList = &tx.AccessList
-		enc.Nonce = (*hexutil.Uint64)(&tx.Nonce)
-		enc.Gas = (*hexutil.Uint64)(&tx.Gas)
-		enc.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap)
-		enc.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap)
-		enc.Value = (*hexutil.Big)(tx.Value)
-		enc.Input = (*hexutil.Bytes)(&tx.Data)
-		enc.To = t.To()
-		enc.V = (*hexutil.Big)(tx.V)
-		enc.R = (*hexutil.Big)(tx.R)
-		enc.S = (*hexutil.Big)(tx.S)
-	case *ShutterTx:
-		enc.ChainID = (*hexutil.Big)(tx.ChainID)
-		enc.Nonce = (*hexutil.Uint64)(&tx.Nonce)
-		enc.Gas = (*hexutil.Uint64)(&tx.Gas)
-		enc.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap)
-		enc.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap)
-		enc.EncryptedPayload = (*hexutil.Bytes)(&tx.EncryptedPayload)
-		enc.L1BlockNumber = (*hexutil.Uint64)(&tx.L1BlockNumber)
-		enc.BatchIndex = (*hexutil.Uint64)(&tx.BatchIndex)
-		if tx.Payload != nil {
-			enc.To = tx.Payload.To
-			enc.Input = (*hexutil.Bytes)(&tx.Payload.Data)
-			enc.Value = (*hexutil.Big)(tx.Payload.Value)
-		}
-		enc.V = (*hexutil.Big)(tx.V)
-		enc.R = (*hexutil.Big)(tx.R)
-		enc.S = (*hexutil.Big)(tx.S)
-	case *BatchTx:
-		enc.ChainID = (*hexutil.Big)(tx.ChainID)
-		if tx.Transactions != nil {
-			enc.Transactions = make([]hexutil.Bytes, len(tx.Transactions))
-			for k, v := range tx.Transactions {
-				enc.Transactions[k] = hexutil.Bytes(v)
-			}
-		}
-		enc.Timestamp = (*hexutil.Big)(tx.Timestamp)
-		enc.DecryptionKey = (*hexutil.Bytes)(&tx.DecryptionKey)
-		enc.L1BlockNumber = (*hexutil.Uint64)(&tx.L1BlockNumber)
-		enc.BatchIndex = (*hexutil.Uint64)(&tx.BatchIndex)
-		enc.V = (*hexutil.Big)(tx.V)
-		enc.R = (*hexutil.Big)(tx.R)
-		enc.S = (*hexutil.Big)(tx.S)
+	// Other fields are set by the codec of the tx type.
+	if codec, ok := txTypeCodecs[t.Type()]; ok {
+		codec.MarshalData(t, enc)
 	}
 	return enc
 }
@@ -190,295 +149,508 @@ func (t *Transaction) UnmarshalJSON(input []byte) error {
 	return t.FromTransactionData(&dec)
 }
 
-func (t *Transaction) FromTransactionData(dec *TransactionData) error {
-	// Decode / verify fields according to transaction type.
-	var inner TxInner
-	switch dec.Type {
-	case LegacyTxType:
-		var itx LegacyTx
-		inner = &itx
-		if dec.To != nil {
-			itx.To = dec.To
+// UnmarshalJSONStrict unmarshals from JSON like UnmarshalJSON, but rejects fields
+// that don't belong to the transaction type, including unknown fields of access
+// list entries and of the decrypted payload. Fields set to null are treated as
+// absent. The informational fields of go-ethereum's RPC output are accepted, so
+// transactions returned by eth_getTransactionByHash can be decoded. If present,
+// the hash must match the hash of the transaction, the chain ID of a legacy
+// transaction must match the one derived from its signature and the sender must
+// match the sender recovered with the signer. The sender is not checked if
+// signer is nil.
+func (t *Transaction) UnmarshalJSONStrict(input []byte, signer Signer) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(input, &fields); err != nil {
+		return err
+	}
+	var dec TransactionData
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	codec, err := txTypeCodec(dec.Type)
+	if err != nil {
+		return err
+	}
+	errs := &txDataErrors{txType: uint8(dec.Type)}
+	allowed := jsonFieldSet(commonJSONFields, codec.JSONFields)
+	for _, name := range unexpectedJSONFields(fields, allowed) {
+		errs.invalid(name, ErrUnexpectedJSONField)
+	}
+	// The nested objects were already decoded into dec, so they are well-formed.
+	if raw, ok := fields["accessList"]; ok && allowed["accessList"] {
+		var tuples []map[string]json.RawMessage
+		json.Unmarshal(raw, &tuples)
+		for i, tuple := range tuples {
+			for _, name := range unexpectedJSONFields(tuple, jsonFieldSet(accessTupleJSONFields)) {
+				errs.invalid(fmt.Sprintf("accessList[%d].%s", i, name), ErrUnexpectedJSONField)
+			}
 		}
-		if dec.Nonce == nil {
-			return errors.New("missing required field 'nonce' in transaction")
+	}
+	if raw, ok := fields["decryptedPayload"]; ok && allowed["decryptedPayload"] {
+		var payload map[string]json.RawMessage
+		json.Unmarshal(raw, &payload)
+		for _, name := range unexpectedJSONFields(payload, jsonFieldSet(decryptedPayloadJSONFields)) {
+			errs.invalid("decryptedPayload."+name, ErrUnexpectedJSONField)
 		}
-		itx.Nonce = uint64(*dec.Nonce)
-		if dec.GasPrice == nil {
-			return errors.New("missing required field 'gasPrice' in transaction")
+	}
+	var tx Transaction
+	if err := tx.FromTransactionData(&dec); err != nil {
+		if decErrs, ok := err.(TransactionDataErrors); ok {
+			errs.errs = append(errs.errs, decErrs...)
+		} else {
+			errs.errs = append(errs.errs, err)
+		}
+		return errs.err()
+	}
+	if value, ok := fields["hash"]; ok && string(value) != "null" && dec.Hash != tx.Hash() {
+		errs.invalid("hash", fmt.Errorf("%w: have %x, want %x", ErrTxHashMismatch, dec.Hash, tx.Hash()))
+	}
+	if tx.Type() == LegacyTxType && dec.ChainID != nil && dec.ChainID.ToInt().Cmp(tx.ChainId()) != 0 {
+		errs.invalid("chainId", fmt.Errorf("%w: have %v, want %v", ErrInvalidChainId, dec.ChainID.ToInt(), tx.ChainId()))
+	}
+	if dec.From != nil && signer != nil {
+		from, err := Sender(signer, &tx)
+		switch {
+		case err != nil:
+			errs.errs = append(errs.errs, &SignatureError{TxType: errs.txType, Err: err})
+		case from != *dec.From:
+			errs.invalid("from", fmt.Errorf("%w: have %x, want %x", ErrTxSenderMismatch, *dec.From, from))
 		}
-		itx.GasPrice = (*big.Int)(dec.GasPrice)
-		if dec.Gas == nil {
-			return errors.New("missing required field 'gas' in transaction")
+	}
+	if err := errs.err(); err != nil {
+		return err
+	}
+	t.setDecoded(tx.inner, 0)
+	return nil
+}
+
+// jsonFieldSet returns the set of the given field names.
+func jsonFieldSet(lists ...[]string) map[string]bool {
+	set := make(map[string]bool)
+	for _, names := range lists {
+		for _, name := range names {
+			set[name] = true
 		}
-		itx.Gas = uint64(*dec.Gas)
-		if dec.Value == nil {
-			return errors.New("missing required field 'value' in transaction")
+	}
+	return set
+}
+
+// unexpectedJSONFields returns the sorted names of the fields which are not
+// allowed. Fields set to null are treated as absent.
+func unexpectedJSONFields(fields map[string]json.RawMessage, allowed map[string]bool) []string {
+	var names []string
+	for name, value := range fields {
+		if !allowed[name] && string(value) != "null" {
+			names = append(names, name)
 		}
-		itx.Value = (*big.Int)(dec.Value)
-		if dec.Input == nil {
-			return errors.New("missing required field 'input' in transaction")
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FromTransactionData sets the transaction from its JSON representation. All
+// problems of the representation are reported at once as TransactionDataErrors.
+func (t *Transaction) FromTransactionData(dec *TransactionData) error {
+	// Decode / verify fields according to transaction type.
+	codec, err := txTypeCodec(dec.Type)
+	if err != nil {
+		return err
+	}
+	inner, err := codec.UnmarshalData(dec)
+	if err != nil {
+		return err
+	}
+
+	// Now set the inner transaction. The hash is only checked by
+	// UnmarshalJSONStrict.
+	t.setDecoded(inner, 0)
+	return nil
+}
+
+// txTypeCodec returns the codec for the type of a JSON transaction.
+func txTypeCodec(typ hexutil.Uint64) (*TxTypeCodec, error) {
+	if uint64(typ) <= 0xff {
+		if codec, ok := txTypeCodecs[byte(typ)]; ok {
+			return codec, nil
 		}
+	}
+	return nil, TransactionDataErrors{&InvalidFieldError{Field: "type", TxType: uint8(typ), Err: ErrTxTypeNotSupported}}
+}
+
+func marshalLegacyTx(t *Transaction, enc *TransactionData) {
+	tx := t.inner.(*LegacyTx)
+	enc.Nonce = (*hexutil.Uint64)(&tx.Nonce)
+	enc.Gas = (*hexutil.Uint64)(&tx.Gas)
+	enc.GasPrice = (*hexutil.Big)(tx.GasPrice)
+	enc.Value = (*hexutil.Big)(tx.Value)
+	enc.Input = (*hexutil.Bytes)(&tx.Data)
+	enc.To = t.To()
+	enc.V = (*hexutil.Big)(tx.V)
+	enc.R = (*hexutil.Big)(tx.R)
+	enc.S = (*hexutil.Big)(tx.S)
+}
+
+func unmarshalLegacyTx(dec *TransactionData) (TxInner, error) {
+	var itx LegacyTx
+	errs := &txDataErrors{txType: LegacyTxType}
+	itx.To = dec.To
+	if dec.Nonce == nil {
+		errs.missing("nonce")
+	} else {
+		itx.Nonce = uint64(*dec.Nonce)
+	}
+	if dec.GasPrice == nil {
+		errs.missing("gasPrice")
+	}
+	itx.GasPrice = (*big.Int)(dec.GasPrice)
+	if dec.Gas == nil {
+		errs.missing("gas")
+	} else {
+		itx.Gas = uint64(*dec.Gas)
+	}
+	if dec.Value == nil {
+		errs.missing("value")
+	}
+	itx.Value = (*big.Int)(dec.Value)
+	if dec.Input == nil {
+		errs.missing("input")
+	} else {
 		itx.Data = *dec.Input
-		if dec.V == nil {
-			return errors.New("missing required field 'v' in transaction")
-		}
-		itx.V = (*big.Int)(dec.V)
-		if dec.R == nil {
-			return errors.New("missing required field 'r' in transaction")
-		}
-		itx.R = (*big.Int)(dec.R)
-		if dec.S == nil {
-			return errors.New("missing required field 's' in transaction")
-		}
-		itx.S = (*big.Int)(dec.S)
-		withSignature := itx.V.Sign() != 0 || itx.R.Sign() != 0 || itx.S.Sign() != 0
-		if withSignature {
-			if err := sanityCheckSignature(itx.V, itx.R, itx.S, true); err != nil {
-				return err
-			}
-		}
+	}
+	itx.V, itx.R, itx.S = errs.signature(dec, true)
+	if err := errs.err(); err != nil {
+		return nil, err
+	}
+	return &itx, nil
+}
 
-	case AccessListTxType:
-		var itx AccessListTx
-		inner = &itx
-		// Access list is optional for now.
-		if dec.AccessList != nil {
-			itx.AccessList = *dec.AccessList
-		}
-		if dec.ChainID == nil {
-			return errors.New("missing required field 'chainId' in transaction")
-		}
-		itx.ChainID = (*big.Int)(dec.ChainID)
-		if dec.To != nil {
-			itx.To = dec.To
-		}
-		if dec.Nonce == nil {
-			return errors.New("missing required field 'nonce' in transaction")
-		}
+func marshalAccessListTx(t *Transaction, enc *TransactionData) {
+	tx := t.inner.(*AccessListTx)
+	enc.ChainID = (*hexutil.Big)(tx.ChainID)
+	enc.AccessList = &tx.AccessList
+	enc.Nonce = (*hexutil.Uint64)(&tx.Nonce)
+	enc.Gas = (*hexutil.Uint64)(&tx.Gas)
+	enc.GasPrice = (*hexutil.Big)(tx.GasPrice)
+	enc.Value = (*hexutil.Big)(tx.Value)
+	enc.Input = (*hexutil.Bytes)(&tx.Data)
+	enc.To = t.To()
+	enc.V = (*hexutil.Big)(tx.V)
+	enc.R = (*hexutil.Big)(tx.R)
+	enc.S = (*hexutil.Big)(tx.S)
+}
+
+func unmarshalAccessListTx(dec *TransactionData) (TxInner, error) {
+	var itx AccessListTx
+	errs := &txDataErrors{txType: AccessListTxType}
+	// Access list is optional for now.
+	if dec.AccessList != nil {
+		itx.AccessList = *dec.AccessList
+	}
+	if dec.ChainID == nil {
+		errs.missing("chainId")
+	}
+	itx.ChainID = (*big.Int)(dec.ChainID)
+	itx.To = dec.To
+	if dec.Nonce == nil {
+		errs.missing("nonce")
+	} else {
 		itx.Nonce = uint64(*dec.Nonce)
-		if dec.GasPrice == nil {
-			return errors.New("missing required field 'gasPrice' in transaction")
-		}
-		itx.GasPrice = (*big.Int)(dec.GasPrice)
-		if dec.Gas == nil {
-			return errors.New("missing required field 'gas' in transaction")
-		}
+	}
+	if dec.GasPrice == nil {
+		errs.missing("gasPrice")
+	}
+	itx.GasPrice = (*big.Int)(dec.GasPrice)
+	if dec.Gas == nil {
+		errs.missing("gas")
+	} else {
 		itx.Gas = uint64(*dec.Gas)
-		if dec.Value == nil {
-			return errors.New("missing required field 'value' in transaction")
-		}
-		itx.Value = (*big.Int)(dec.Value)
-		if dec.Input == nil {
-			return errors.New("missing required field 'input' in transaction")
-		}
+	}
+	if dec.Value == nil {
+		errs.missing("value")
+	}
+	itx.Value = (*big.Int)(dec.Value)
+	if dec.Input == nil {
+		errs.missing("input")
+	} else {
 		itx.Data = *dec.Input
-		if dec.V == nil {
-			return errors.New("missing required field 'v' in transaction")
-		}
-		itx.V = (*big.Int)(dec.V)
-		if dec.R == nil {
-			return errors.New("missing required field 'r' in transaction")
-		}
-		itx.R = (*big.Int)(dec.R)
-		if dec.S == nil {
-			return errors.New("missing required field 's' in transaction")
-		}
-		itx.S = (*big.Int)(dec.S)
-		withSignature := itx.V.Sign() != 0 || itx.R.Sign() != 0 || itx.S.Sign() != 0
-		if withSignature {
-			if err := sanityCheckSignature(itx.V, itx.R, itx.S, false); err != nil {
-				return err
-			}
-		}
+	}
+	itx.V, itx.R, itx.S = errs.signature(dec, false)
+	if err := errs.err(); err != nil {
+		return nil, err
+	}
+	return &itx, nil
+}
 
-	case DynamicFeeTxType:
-		var itx DynamicFeeTx
-		inner = &itx
-		// Access list is optional for now.
-		if dec.AccessList != nil {
-			itx.AccessList = *dec.AccessList
-		}
-		if dec.ChainID == nil {
-			return errors.New("missing required field 'chainId' in transaction")
-		}
-		itx.ChainID = (*big.Int)(dec.ChainID)
-		if dec.To != nil {
-			itx.To = dec.To
-		}
-		if dec.Nonce == nil {
-			return errors.New("missing required field 'nonce' in transaction")
-		}
+func marshalDynamicFeeTx(t *Transaction, enc *TransactionData) {
+	tx := t.inner.(*DynamicFeeTx)
+	enc.ChainID = (*hexutil.Big)(tx.ChainID)
+	enc.AccessList = &tx.AccessList
+	enc.Nonce = (*hexutil.Uint64)(&tx.Nonce)
+	enc.Gas = (*hexutil.Uint64)(&tx.Gas)
+	enc.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap)
+	enc.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap)
+	enc.Value = (*hexutil.Big)(tx.Value)
+	enc.Input = (*hexutil.Bytes)(&tx.Data)
+	enc.To = t.To()
+	enc.V = (*hexutil.Big)(tx.V)
+	enc.R = (*hexutil.Big)(tx.R)
+	enc.S = (*hexutil.Big)(tx.S)
+}
+
+func unmarshalDynamicFeeTx(dec *TransactionData) (TxInner, error) {
+	var itx DynamicFeeTx
+	errs := &txDataErrors{txType: DynamicFeeTxType}
+	// Access list is optional for now.
+	if dec.AccessList != nil {
+		itx.AccessList = *dec.AccessList
+	}
+	if dec.ChainID == nil {
+		errs.missing("chainId")
+	}
+	itx.ChainID = (*big.Int)(dec.ChainID)
+	itx.To = dec.To
+	if dec.Nonce == nil {
+		errs.missing("nonce")
+	} else {
 		itx.Nonce = uint64(*dec.Nonce)
-		if dec.MaxPriorityFeePerGas == nil {
-			return errors.New("missing required field 'maxPriorityFeePerGas' for txdata")
-		}
-		itx.GasTipCap = (*big.Int)(dec.MaxPriorityFeePerGas)
-		if dec.MaxFeePerGas == nil {
-			return errors.New("missing required field 'maxFeePerGas' for txdata")
-		}
-		itx.GasFeeCap = (*big.Int)(dec.MaxFeePerGas)
-		if dec.Gas == nil {
-			return errors.New("missing required field 'gas' for txdata")
-		}
+	}
+	if dec.MaxPriorityFeePerGas == nil {
+		errs.missing("maxPriorityFeePerGas")
+	}
+	itx.GasTipCap = (*big.Int)(dec.MaxPriorityFeePerGas)
+	if dec.MaxFeePerGas == nil {
+		errs.missing("maxFeePerGas")
+	}
+	itx.GasFeeCap = (*big.Int)(dec.MaxFeePerGas)
+	if dec.Gas == nil {
+		errs.missing("gas")
+	} else {
 		itx.Gas = uint64(*dec.Gas)
-		if dec.Value == nil {
-			return errors.New("missing required field 'value' in transaction")
-		}
-		itx.Value = (*big.Int)(dec.Value)
-		if dec.Input == nil {
-			return errors.New("missing required field 'input' in transaction")
-		}
+	}
+	if dec.Value == nil {
+		errs.missing("value")
+	}
+	itx.Value = (*big.Int)(dec.Value)
+	if dec.Input == nil {
+		errs.missing("input")
+	} else {
 		itx.Data = *dec.Input
-		if dec.V == nil {
-			return errors.New("missing required field 'v' in transaction")
-		}
-		itx.V = (*big.Int)(dec.V)
-		if dec.R == nil {
-			return errors.New("missing required field 'r' in transaction")
-		}
-		itx.R = (*big.Int)(dec.R)
-		if dec.S == nil {
-			return errors.New("missing required field 's' in transaction")
-		}
-		itx.S = (*big.Int)(dec.S)
-		withSignature := itx.V.Sign() != 0 || itx.R.Sign() != 0 || itx.S.Sign() != 0
-		if withSignature {
-			if err := sanityCheckSignature(itx.V, itx.R, itx.S, false); err != nil {
-				return err
-			}
-		}
-	case ShutterTxType:
-		var itx ShutterTx
-		inner = &itx
-		if dec.ChainID == nil {
-			return errors.New("missing required field 'chainId' in transaction")
-		}
-		itx.ChainID = (*big.Int)(dec.ChainID)
-		if dec.Nonce == nil {
-			return errors.New("missing required field 'nonce' in transaction")
-		}
+	}
+	itx.V, itx.R, itx.S = errs.signature(dec, false)
+	if err := errs.err(); err != nil {
+		return nil, err
+	}
+	return &itx, nil
+}
+
+func marshalShutterTx(t *Transaction, enc *TransactionData) {
+	tx := t.inner.(*ShutterTx)
+	enc.ChainID = (*hexutil.Big)(tx.ChainID)
+	enc.Nonce = (*hexutil.Uint64)(&tx.Nonce)
+	enc.Gas = (*hexutil.Uint64)(&tx.Gas)
+	enc.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap)
+	enc.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap)
+	enc.EncryptedPayload = (*hexutil.Bytes)(&tx.EncryptedPayload)
+	enc.L1BlockNumber = (*hexutil.Uint64)(&tx.L1BlockNumber)
+	enc.BatchIndex = (*hexutil.Uint64)(&tx.BatchIndex)
+	status := tx.DecryptionStatus
+	if tx.Payload != nil {
+		enc.DecryptedPayload = &DecryptedPayloadData{
+			To:    tx.Payload.To,
+			Value: (*hexutil.Big)(tx.Payload.Value),
+			Data:  (*hexutil.Bytes)(&tx.Payload.Data),
+		}
+		// A payload is only known once the transaction is decrypted.
+		status = DecryptionStatusDecrypted
+	}
+	decryptionStatus := hexutil.Uint64(status)
+	enc.DecryptionStatus = &decryptionStatus
+	enc.V = (*hexutil.Big)(tx.V)
+	enc.R = (*hexutil.Big)(tx.R)
+	enc.S = (*hexutil.Big)(tx.S)
+}
+
+func unmarshalShutterTx(dec *TransactionData) (TxInner, error) {
+	var itx ShutterTx
+	errs := &txDataErrors{txType: ShutterTxType}
+	if dec.ChainID == nil {
+		errs.missing("chainId")
+	}
+	itx.ChainID = (*big.Int)(dec.ChainID)
+	if dec.Nonce == nil {
+		errs.missing("nonce")
+	} else {
 		itx.Nonce = uint64(*dec.Nonce)
-		if dec.MaxPriorityFeePerGas == nil {
-			return errors.New("missing required field 'maxPriorityFeePerGas' for txdata")
-		}
-		itx.GasTipCap = (*big.Int)(dec.MaxPriorityFeePerGas)
-		if dec.MaxFeePerGas == nil {
-			return errors.New("missing required field 'maxFeePerGas' for txdata")
-		}
-		itx.GasFeeCap = (*big.Int)(dec.MaxFeePerGas)
-		if dec.Gas == nil {
-			return errors.New("missing required field 'gas' for txdata")
-		}
+	}
+	if dec.MaxPriorityFeePerGas == nil {
+		errs.missing("maxPriorityFeePerGas")
+	}
+	itx.GasTipCap = (*big.Int)(dec.MaxPriorityFeePerGas)
+	if dec.MaxFeePerGas == nil {
+		errs.missing("maxFeePerGas")
+	}
+	itx.GasFeeCap = (*big.Int)(dec.MaxFeePerGas)
+	if dec.Gas == nil {
+		errs.missing("gas")
+	} else {
 		itx.Gas = uint64(*dec.Gas)
-		if dec.L1BlockNumber == nil {
-			return errors.New("missing required field 'l1BlockNumber' in transaction")
-		}
+	}
+	if dec.L1BlockNumber == nil {
+		errs.missing("l1BlockNumber")
+	} else {
 		itx.L1BlockNumber = uint64(*dec.L1BlockNumber)
+	}
+	if dec.EncryptedPayload == nil {
+		errs.missing("encryptedPayload")
+	} else {
+		itx.EncryptedPayload = *dec.EncryptedPayload
+	}
+	if dec.BatchIndex == nil {
+		errs.missing("batchIndex")
+	} else {
+		itx.BatchIndex = uint64(*dec.BatchIndex)
+	}
 
-		if dec.EncryptedPayload == nil {
-			return errors.New("missing required field 'encryptedPayload' in transaction")
-		}
+	// The decryptionStatus field is always emitted with the nested payload.
+	// Without it, the payload is given in the flattened shape.
+	if dec.DecryptionStatus != nil {
+		unmarshalDecryptedPayload(dec, &itx, errs)
+	} else {
+		unmarshalFlattenedPayload(dec, &itx, errs)
+	}
 
-		itx.EncryptedPayload = *dec.EncryptedPayload
+	itx.V, itx.R, itx.S = errs.signature(dec, false)
+	if err := errs.err(); err != nil {
+		return nil, err
+	}
+	return &itx, nil
+}
 
-		if dec.BatchIndex == nil {
-			return errors.New("missing required field 'batchIndex' in transaction")
-		}
-		itx.BatchIndex = uint64(*dec.BatchIndex)
-		if dec.V == nil {
-			return errors.New("missing required field 'v' in transaction")
+// unmarshalDecryptedPayload sets the decrypted payload and the decryption status
+// of a Shutter transaction, which are given in the decryptedPayload and
+// decryptionStatus fields.
+func unmarshalDecryptedPayload(dec *TransactionData, itx *ShutterTx, errs *txDataErrors) {
+	errPayloadField := errors.New("payload field outside of decryptedPayload")
+	if dec.To != nil {
+		errs.invalid("to", errPayloadField)
+	}
+	if dec.Value != nil {
+		errs.invalid("value", errPayloadField)
+	}
+	if dec.Input != nil {
+		errs.invalid("input", errPayloadField)
+	}
+	status := DecryptionStatus(*dec.DecryptionStatus)
+	if uint64(*dec.DecryptionStatus) > uint64(DecryptionStatusWrongBatchIndex) || status == DecryptionStatusDecodeFailed {
+		errs.invalid("decryptionStatus", fmt.Errorf("invalid decryption status %d", uint64(*dec.DecryptionStatus)))
+		return
+	}
+	itx.DecryptionStatus = status
+	switch {
+	case status == DecryptionStatusDecrypted && dec.DecryptedPayload == nil:
+		errs.missing("decryptedPayload")
+	case status != DecryptionStatusDecrypted && dec.DecryptedPayload != nil:
+		errs.invalid("decryptedPayload", fmt.Errorf("payload with decryption status '%v'", status))
+	case dec.DecryptedPayload != nil:
+		itx.Payload = &ShutterPayload{
+			To:    dec.DecryptedPayload.To,
+			Value: new(big.Int),
+		}
+		if dec.DecryptedPayload.Value != nil {
+			itx.Payload.Value = (*big.Int)(dec.DecryptedPayload.Value)
+		}
+		if dec.DecryptedPayload.Data != nil {
+			itx.Payload.Data = *dec.DecryptedPayload.Data
 		}
+	}
+}
 
-		hasTo := bool(dec.To != nil)
-		hasValue := bool(dec.Value != nil)
-		hasInput := bool(dec.Input != nil)
-		if hasTo || hasValue || hasInput {
-			itx.Payload = &ShutterPayload{
-				To: dec.To,
-			}
-			if hasInput {
-				// optional
-				itx.Payload.Data = *dec.Input
-			}
-			if !hasValue {
-				// this is only required when there are other payload values set
-				return errors.New("missing required nested field 'value' in transaction payload")
-			}
+// unmarshalFlattenedPayload sets the decrypted payload of a Shutter transaction
+// from the to, value and input fields, as encoded before the decryptedPayload
+// field was introduced.
+func unmarshalFlattenedPayload(dec *TransactionData, itx *ShutterTx, errs *txDataErrors) {
+	if dec.DecryptedPayload != nil {
+		errs.missing("decryptionStatus")
+	}
+	hasTo := bool(dec.To != nil)
+	hasValue := bool(dec.Value != nil)
+	hasInput := bool(dec.Input != nil)
+	if hasTo || hasValue || hasInput {
+		itx.Payload = &ShutterPayload{
+			To: dec.To,
+		}
+		itx.DecryptionStatus = DecryptionStatusDecrypted
+		if hasInput {
+			// optional
+			itx.Payload.Data = *dec.Input
+		}
+		if !hasValue {
+			// this is only required when there are other payload values set
+			errs.missing("value")
+		} else {
 			itx.Payload.Value = dec.Value.ToInt()
 		}
+	}
+}
 
-		itx.V = (*big.Int)(dec.V)
-		if dec.R == nil {
-			return errors.New("missing required field 'r' in transaction")
-		}
-		itx.R = (*big.Int)(dec.R)
-		if dec.S == nil {
-			return errors.New("missing required field 's' in transaction")
-		}
-		itx.S = (*big.Int)(dec.S)
-		withSignature := itx.V.Sign() != 0 || itx.R.Sign() != 0 || itx.S.Sign() != 0
-		if withSignature {
-			if err := sanityCheckSignature(itx.V, itx.R, itx.S, false); err != nil {
-				return err
-			}
-		}
-	case BatchTxType:
-		var itx BatchTx
-		inner = &itx
-		if dec.ChainID == nil {
-			return errors.New("missing required field 'chainId' in transaction")
+func marshalBatchTx(t *Transaction, enc *TransactionData) {
+	tx := t.inner.(*BatchTx)
+	enc.ChainID = (*hexutil.Big)(tx.ChainID)
+	if tx.Transactions != nil {
+		enc.Transactions = make([]hexutil.Bytes, len(tx.Transactions))
+		for k, v := range tx.Transactions {
+			enc.Transactions[k] = hexutil.Bytes(v)
 		}
-		itx.ChainID = (*big.Int)(dec.ChainID)
+	}
+	enc.Timestamp = (*hexutil.Big)(tx.Timestamp)
+	enc.DecryptionKey = (*hexutil.Bytes)(&tx.DecryptionKey)
+	enc.L1BlockNumber = (*hexutil.Uint64)(&tx.L1BlockNumber)
+	enc.BatchIndex = (*hexutil.Uint64)(&tx.BatchIndex)
+	enc.V = (*hexutil.Big)(tx.V)
+	enc.R = (*hexutil.Big)(tx.R)
+	enc.S = (*hexutil.Big)(tx.S)
+}
 
-		if dec.Timestamp == nil {
-			return errors.New("missing required field 'timestamp' in transaction")
-		}
-		itx.Timestamp = (*big.Int)(dec.Timestamp)
+func unmarshalBatchTx(dec *TransactionData) (TxInner, error) {
+	var itx BatchTx
+	errs := &txDataErrors{txType: BatchTxType}
+	if dec.ChainID == nil {
+		errs.missing("chainId")
+	}
+	itx.ChainID = (*big.Int)(dec.ChainID)
 
-		if dec.Transactions == nil {
-			return errors.New("missing required field 'transactions' in transaction")
-		}
+	if dec.DecryptionKey != nil {
+		itx.DecryptionKey = *dec.DecryptionKey
+	}
+
+	if dec.Timestamp == nil {
+		errs.missing("timestamp")
+	}
+	itx.Timestamp = (*big.Int)(dec.Timestamp)
+
+	if dec.Transactions == nil {
+		errs.missing("transactions")
+	} else {
 		itx.Transactions = make([][]byte, len(dec.Transactions))
 		for i, txx := range dec.Transactions {
 			itx.Transactions[i] = []byte(txx)
 		}
+	}
 
-		if dec.L1BlockNumber == nil {
-			return errors.New("missing required field 'l1BlockNumber' in transaction")
-		}
+	if dec.L1BlockNumber == nil {
+		errs.missing("l1BlockNumber")
+	} else {
 		itx.L1BlockNumber = uint64(*dec.L1BlockNumber)
+	}
 
-		if dec.BatchIndex == nil {
-			return errors.New("missing required field 'batchIndex' in transaction")
-		}
+	if dec.BatchIndex == nil {
+		errs.missing("batchIndex")
+	} else {
 		itx.BatchIndex = uint64(*dec.BatchIndex)
-		if dec.V == nil {
-			return errors.New("missing required field 'v' in transaction")
-		}
-		itx.V = (*big.Int)(dec.V)
-		if dec.R == nil {
-			return errors.New("missing required field 'r' in transaction")
-		}
-		itx.R = (*big.Int)(dec.R)
-		if dec.S == nil {
-			return errors.New("missing required field 's' in transaction")
-		}
-		itx.S = (*big.Int)(dec.S)
-		withSignature := itx.V.Sign() != 0 || itx.R.Sign() != 0 || itx.S.Sign() != 0
-		if withSignature {
-			if err := sanityCheckSignature(itx.V, itx.R, itx.S, false); err != nil {
-				return err
-			}
-		}
-
-	default:
-		return ErrTxTypeNotSupported
 	}
-
-	// Now set the inner transaction.
-	t.setDecoded(inner, 0)
-
-	// TODO: check hash here?
-	return nil
+	itx.V, itx.R, itx.S = errs.signature(dec, false)
+	if err := errs.err(); err != nil {
+		return nil, err
+	}
+	return &itx, nil
 }
@@ -0,0 +1,266 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestBatchTxJSONRoundTrip(t *testing.T) {
+	shutter := newTestShutterTx(t, testShutterPay, 5, 0)
+	batch, err := SignNewTx(testKey, NewShutterSigner(testChainID), &BatchTx{
+		ChainID:       testChainID,
+		DecryptionKey: AESTestScheme{}.DecryptionKey(testEonKey, 5),
+		BatchIndex:    5,
+		L1BlockNumber: 7,
+		Timestamp:     big.NewInt(1000),
+		Transactions:  [][]byte{mustMarshalBinary(t, shutter)},
+	})
+	if err != nil {
+		t.Fatalf("failed to sign batch: %v", err)
+	}
+	enc, err := json.Marshal(batch)
+	if err != nil {
+		t.Fatalf("failed to encode batch: %v", err)
+	}
+	for _, strict := range []bool{false, true} {
+		dec := new(Transaction)
+		if strict {
+			err = dec.UnmarshalJSONStrict(enc, NewShutterSigner(testChainID))
+		} else {
+			err = dec.UnmarshalJSON(enc)
+		}
+		if err != nil {
+			t.Fatalf("strict %t: failed to decode batch: %v", strict, err)
+		}
+		if dec.Hash() != batch.Hash() {
+			t.Errorf("strict %t: wrong hash: have %v, want %v", strict, dec.Hash(), batch.Hash())
+		}
+		if string(dec.DecryptionKey()) != string(batch.DecryptionKey()) {
+			t.Errorf("strict %t: wrong decryption key: have %x, want %x", strict, dec.DecryptionKey(), batch.DecryptionKey())
+		}
+	}
+}
+
+// withJSONFields returns the JSON encoding of the transaction with the given
+// fields added, like in the output of go-ethereum's RPC.
+func withJSONFields(t *testing.T, tx *Transaction, fields map[string]interface{}) []byte {
+	t.Helper()
+	enc, err := json.Marshal(tx)
+	if err != nil {
+		t.Fatalf("failed to encode tx: %v", err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(enc, &m); err != nil {
+		t.Fatalf("failed to decode tx: %v", err)
+	}
+	for name, value := range fields {
+		m[name] = value
+	}
+	enc, err = json.Marshal(m)
+	if err != nil {
+		t.Fatalf("failed to encode tx: %v", err)
+	}
+	return enc
+}
+
+func TestUnmarshalJSONStrictRPCFields(t *testing.T) {
+	signer := NewShutterSigner(testChainID)
+	legacy := MustSignNewTx(testKey, signer, &LegacyTx{Nonce: 1, GasPrice: big.NewInt(10), Gas: 21000, To: &testRecipient, Value: big.NewInt(1)})
+	dynamic := MustSignNewTx(testKey, signer, &DynamicFeeTx{ChainID: testChainID, Nonce: 2, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(10), Gas: 21000, To: &testRecipient, Value: big.NewInt(1)})
+	included := map[string]interface{}{
+		"from":             testAddr,
+		"blockHash":        "0x1a2d3f76c2d1b9d0e8a2c5fcb7a0f6d1b3c4d5e6f708192a3b4c5d6e7f809112",
+		"blockNumber":      "0x10",
+		"transactionIndex": "0x0",
+	}
+
+	tests := []struct {
+		tx     *Transaction
+		fields map[string]interface{}
+		err    error
+		field  string
+	}{
+		{tx: legacy, fields: map[string]interface{}{"chainId": "0x1"}},
+		{tx: legacy, fields: included},
+		{tx: dynamic, fields: map[string]interface{}{"gasPrice": "0x6"}},
+		{tx: dynamic, fields: included},
+		{tx: legacy, fields: map[string]interface{}{"chainId": "0x2"}, err: ErrInvalidChainId, field: "chainId"},
+		{tx: legacy, fields: map[string]interface{}{"maxFeePerGas": "0x1"}, err: ErrUnexpectedJSONField, field: "maxFeePerGas"},
+		{tx: dynamic, fields: map[string]interface{}{"foo": "0x1"}, err: ErrUnexpectedJSONField, field: "foo"},
+		{tx: dynamic, fields: map[string]interface{}{"from": testRecipient}, err: ErrTxSenderMismatch, field: "from"},
+	}
+	for i, tt := range tests {
+		var dec Transaction
+		err := dec.UnmarshalJSONStrict(withJSONFields(t, tt.tx, tt.fields), signer)
+		if tt.err == nil {
+			if err != nil {
+				t.Errorf("test %d: failed to decode: %v", i, err)
+			} else if dec.Hash() != tt.tx.Hash() {
+				t.Errorf("test %d: wrong hash: have %v, want %v", i, dec.Hash(), tt.tx.Hash())
+			}
+			continue
+		}
+		var fieldErr *InvalidFieldError
+		if !errors.Is(err, tt.err) || !errors.As(err, &fieldErr) || fieldErr.Field != tt.field {
+			t.Errorf("test %d: wrong error: have %v, want %v in field %q", i, err, tt.err, tt.field)
+		}
+	}
+}
+
+func TestUnmarshalJSONStrictNestedFields(t *testing.T) {
+	signer := NewShutterSigner(testChainID)
+	dynamic := MustSignNewTx(testKey, signer, &DynamicFeeTx{
+		ChainID:    testChainID,
+		Nonce:      2,
+		GasTipCap:  big.NewInt(1),
+		GasFeeCap:  big.NewInt(10),
+		Gas:        30000,
+		To:         &testRecipient,
+		AccessList: AccessList{{Address: testRecipient}, {Address: testAddr}},
+	})
+	encrypted := newTestShutterTx(t, testShutterPay, 5, 0)
+	decrypted, err := encrypted.Decrypt(AESTestScheme{}.DecryptionKey(testEonKey, 5), AESTestScheme{})
+	if err != nil {
+		t.Fatalf("failed to decrypt: %v", err)
+	}
+
+	accessTuple := func(i int) func(map[string]interface{}) map[string]interface{} {
+		return func(m map[string]interface{}) map[string]interface{} {
+			return m["accessList"].([]interface{})[i].(map[string]interface{})
+		}
+	}
+	payload := func(m map[string]interface{}) map[string]interface{} {
+		return m["decryptedPayload"].(map[string]interface{})
+	}
+
+	tests := []struct {
+		tx     *Transaction
+		object func(map[string]interface{}) map[string]interface{} // nested object to add the field to
+		value  interface{}
+		field  string
+	}{
+		{tx: dynamic, object: accessTuple(1), value: "0x1", field: "accessList[1].foo"},
+		{tx: dynamic, object: accessTuple(0), value: nil},
+		{tx: decrypted, object: payload, value: "0x1", field: "decryptedPayload.foo"},
+		{tx: decrypted, object: payload, value: nil},
+	}
+	for i, tt := range tests {
+		enc, err := json.Marshal(tt.tx)
+		if err != nil {
+			t.Fatalf("test %d: failed to encode tx: %v", i, err)
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal(enc, &m); err != nil {
+			t.Fatalf("test %d: failed to decode tx: %v", i, err)
+		}
+		tt.object(m)["foo"] = tt.value
+		if enc, err = json.Marshal(m); err != nil {
+			t.Fatalf("test %d: failed to encode tx: %v", i, err)
+		}
+
+		var dec Transaction
+		err = dec.UnmarshalJSONStrict(enc, signer)
+		if tt.field == "" {
+			if err != nil {
+				t.Errorf("test %d: failed to decode: %v", i, err)
+			}
+			continue
+		}
+		var fieldErr *InvalidFieldError
+		if !errors.Is(err, ErrUnexpectedJSONField) || !errors.As(err, &fieldErr) || fieldErr.Field != tt.field {
+			t.Errorf("test %d: wrong error: have %v, want %v in field %q", i, err, ErrUnexpectedJSONField, tt.field)
+		}
+		// The lenient decoding ignores unknown fields.
+		if err := dec.UnmarshalJSON(enc); err != nil || dec.Hash() != tt.tx.Hash() {
+			t.Errorf("test %d: lenient decoding failed: %v", i, err)
+		}
+	}
+}
+
+func TestShutterTxJSON(t *testing.T) {
+	encrypted := newTestShutterTx(t, testShutterPay, 5, 0)
+	decrypted, err := encrypted.Decrypt(AESTestScheme{}.DecryptionKey(testEonKey, 5), AESTestScheme{})
+	if err != nil {
+		t.Fatalf("failed to decrypt: %v", err)
+	}
+
+	// The nested shape round-trips for encrypted and decrypted transactions.
+	for _, tx := range []*Transaction{encrypted, decrypted} {
+		enc, err := json.Marshal(tx)
+		if err != nil {
+			t.Fatalf("failed to encode tx: %v", err)
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(enc, &fields); err != nil {
+			t.Fatalf("failed to decode fields: %v", err)
+		}
+		if _, ok := fields["decryptionStatus"]; !ok {
+			t.Errorf("status %v: decryptionStatus missing in %s", tx.DecryptionStatus(), enc)
+		}
+		for _, name := range []string{"status", "to", "value", "input"} {
+			if value, ok := fields[name]; ok && string(value) != "null" {
+				t.Errorf("status %v: unexpected field %q in %s", tx.DecryptionStatus(), name, enc)
+			}
+		}
+		var dec Transaction
+		if err := dec.UnmarshalJSONStrict(enc, NewShutterSigner(testChainID)); err != nil {
+			t.Fatalf("status %v: failed to decode tx: %v", tx.DecryptionStatus(), err)
+		}
+		if dec.Hash() != tx.Hash() || dec.DecryptionStatus() != tx.DecryptionStatus() {
+			t.Errorf("status %v: wrong decoded tx: hash %v, status %v", tx.DecryptionStatus(), dec.Hash(), dec.DecryptionStatus())
+		}
+		if (dec.To() == nil) != (tx.To() == nil) || string(dec.Data()) != string(tx.Data()) || dec.Value().Cmp(tx.Value()) != 0 {
+			t.Errorf("status %v: wrong decoded payload", tx.DecryptionStatus())
+		}
+	}
+
+	// The flattened shape written before the nested payload is still accepted.
+	flattened := withJSONFields(t, encrypted, map[string]interface{}{
+		"decryptionStatus": nil,
+		"to":               testRecipient,
+		"value":            "0x2a",
+		"input":            "0xdeadbeef",
+	})
+	var dec Transaction
+	if err := dec.UnmarshalJSON(flattened); err != nil {
+		t.Fatalf("failed to decode flattened tx: %v", err)
+	}
+	if dec.Hash() != encrypted.Hash() || dec.DecryptionStatus() != DecryptionStatusDecrypted {
+		t.Errorf("wrong flattened tx: hash %v, status %v", dec.Hash(), dec.DecryptionStatus())
+	}
+	if to := dec.To(); to == nil || *to != testRecipient || dec.Value().Int64() != 42 || string(dec.Data()) != string(testShutterPay.Data) {
+		t.Errorf("wrong flattened payload: to %v, value %v, data %x", dec.To(), dec.Value(), dec.Data())
+	}
+
+	// Mixing the shapes and impossible statuses are rejected.
+	payload := map[string]interface{}{"to": testRecipient, "value": "0x1", "data": "0x"}
+	tests := []struct {
+		fields  map[string]interface{}
+		field   string
+		missing bool
+	}{
+		{fields: map[string]interface{}{"decryptionStatus": "0x1", "decryptedPayload": payload, "to": testRecipient}, field: "to"},
+		{fields: map[string]interface{}{"decryptionStatus": "0x1", "decryptedPayload": payload, "input": "0x"}, field: "input"},
+		{fields: map[string]interface{}{"decryptionStatus": "0x2"}, field: "decryptionStatus"},
+		{fields: map[string]interface{}{"decryptionStatus": "0x5"}, field: "decryptionStatus"},
+		{fields: map[string]interface{}{"decryptionStatus": "0x3", "decryptedPayload": payload}, field: "decryptedPayload"},
+		{fields: map[string]interface{}{"decryptionStatus": "0x1", "decryptedPayload": nil}, field: "decryptedPayload", missing: true},
+		{fields: map[string]interface{}{"decryptionStatus": nil, "decryptedPayload": payload}, field: "decryptionStatus", missing: true},
+	}
+	for i, tt := range tests {
+		var dec Transaction
+		err := dec.UnmarshalJSON(withJSONFields(t, encrypted, tt.fields))
+		var (
+			invalidErr *InvalidFieldError
+			missingErr *MissingFieldError
+		)
+		switch {
+		case tt.missing && !(errors.As(err, &missingErr) && missingErr.Field == tt.field):
+			t.Errorf("test %d: wrong error: have %v, want missing field %q", i, err, tt.field)
+		case !tt.missing && !(errors.As(err, &invalidErr) && invalidErr.Field == tt.field):
+			t.Errorf("test %d: wrong error: have %v, want invalid field %q", i, err, tt.field)
+		}
+	}
+}
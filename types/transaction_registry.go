@@ -0,0 +1,117 @@
+package types
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TxTypeCodec describes how a transaction type is decoded, converted to and from
+// its JSON representation and hashed for signing.
+type TxTypeCodec struct {
+	// NewInner returns an empty inner transaction that the RLP payload of a
+	// typed transaction envelope is decoded into.
+	NewInner func() TxInner
+
+	// MarshalData sets the type specific fields of the JSON representation.
+	MarshalData func(tx *Transaction, enc *TransactionData)
+
+	// UnmarshalData creates the inner transaction from its JSON representation.
+	UnmarshalData func(dec *TransactionData) (TxInner, error)
+
+	// JSONFields lists the type specific fields of the JSON representation,
+	// including informational fields emitted by go-ethereum's RPC, such as the
+	// chainId of legacy and the effective gasPrice of dynamic fee transactions.
+	// UnmarshalJSONStrict rejects all other fields except for the ones common
+	// to all types. It must not be nil.
+	JSONFields []string
+
+	// SigningHash returns the hash to be signed by the sender for the given
+	// chain ID. It is nil for legacy transactions, whose hash depends on the
+	// signer.
+	SigningHash func(tx *Transaction, chainID *big.Int) common.Hash
+}
+
+// txTypeCodecs holds the codecs of all supported transaction types.
+var txTypeCodecs = make(map[byte]*TxTypeCodec)
+
+func init() {
+	RegisterTxType(LegacyTxType, &TxTypeCodec{
+		NewInner:      func() TxInner { return new(LegacyTx) },
+		MarshalData:   marshalLegacyTx,
+		UnmarshalData: unmarshalLegacyTx,
+		JSONFields:    []string{"chainId", "nonce", "gasPrice", "gas", "to", "value", "input"},
+	})
+	RegisterTxType(AccessListTxType, &TxTypeCodec{
+		NewInner:      func() TxInner { return new(AccessListTx) },
+		MarshalData:   marshalAccessListTx,
+		UnmarshalData: unmarshalAccessListTx,
+		JSONFields:    []string{"chainId", "accessList", "nonce", "gasPrice", "gas", "to", "value", "input"},
+		SigningHash:   accessListTxSigningHash,
+	})
+	RegisterTxType(DynamicFeeTxType, &TxTypeCodec{
+		NewInner:      func() TxInner { return new(DynamicFeeTx) },
+		MarshalData:   marshalDynamicFeeTx,
+		UnmarshalData: unmarshalDynamicFeeTx,
+		JSONFields:    []string{"chainId", "accessList", "nonce", "gasPrice", "maxPriorityFeePerGas", "maxFeePerGas", "gas", "to", "value", "input"},
+		SigningHash:   dynamicFeeTxSigningHash,
+	})
+	RegisterTxType(ShutterTxType, &TxTypeCodec{
+		NewInner:      func() TxInner { return new(ShutterTx) },
+		MarshalData:   marshalShutterTx,
+		UnmarshalData: unmarshalShutterTx,
+		JSONFields:    []string{"chainId", "nonce", "maxPriorityFeePerGas", "maxFeePerGas", "gas", "encryptedPayload", "batchIndex", "l1BlockNumber", "decryptedPayload", "decryptionStatus", "to", "value", "input"},
+		SigningHash:   shutterTxSigningHash,
+	})
+	RegisterTxType(BatchTxType, &TxTypeCodec{
+		NewInner:      func() TxInner { return new(BatchTx) },
+		MarshalData:   marshalBatchTx,
+		UnmarshalData: unmarshalBatchTx,
+		JSONFields:    []string{"chainId", "decryptionKey", "batchIndex", "l1BlockNumber", "timestamp", "transactions"},
+		SigningHash:   batchTxSigningHash,
+	})
+}
+
+// RegisterTxType registers the codec of a transaction type. Registered types are
+// decoded from their RLP and JSON encodings, accepted in receipts and signed by
+// the signer returned by NewShutterSigner. The inner transaction only has to
+// implement TxInnerExtension if the type carries Shutter fields.
+//
+// RegisterTxType is not safe for concurrent use and should be called from init
+// functions. It panics if the type is already registered, is not a valid
+// EIP-2718 type or if the codec is incomplete.
+func RegisterTxType(typ byte, codec *TxTypeCodec) {
+	if typ > 0x7f {
+		panic(fmt.Sprintf("invalid transaction type %#x", typ))
+	}
+	if _, ok := txTypeCodecs[typ]; ok {
+		panic(fmt.Sprintf("transaction type %#x already registered", typ))
+	}
+	if codec.NewInner == nil || codec.MarshalData == nil || codec.UnmarshalData == nil || codec.JSONFields == nil {
+		panic(fmt.Sprintf("incomplete codec for transaction type %#x", typ))
+	}
+	if typ != LegacyTxType && codec.SigningHash == nil {
+		panic(fmt.Sprintf("missing signing hash for transaction type %#x", typ))
+	}
+	txTypeCodecs[typ] = codec
+}
+
+// isTypedTxRegistered returns whether typ is a registered EIP-2718 transaction type.
+func isTypedTxRegistered(typ byte) bool {
+	_, ok := txTypeCodecs[typ]
+	return ok && typ != LegacyTxType
+}
+
+// typedTxSigningHash returns the signing hash of a typed transaction as defined
+// by the codec of its type.
+func typedTxSigningHash(tx *Transaction, chainID *big.Int) common.Hash {
+	codec, ok := txTypeCodecs[tx.Type()]
+	if !ok || codec.SigningHash == nil {
+		// This _should_ not happen, but in case someone sends in a bad
+		// json struct via RPC, it's probably more prudent to return an
+		// empty hash instead of killing the node with a panic
+		return common.Hash{}
+	}
+	return codec.SigningHash(tx, chainID)
+}
@@ -0,0 +1,131 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+// registryTestTxType is the type ID of registryTestTx.
+const registryTestTxType = 0x7e
+
+// registryTestTx is a dynamic fee transaction with another type ID, registered
+// to test the dispatch through the registry.
+type registryTestTx struct {
+	DynamicFeeTx
+}
+
+func (tx *registryTestTx) txType() byte { return registryTestTxType }
+
+func (tx *registryTestTx) copy() TxInner {
+	return &registryTestTx{*tx.DynamicFeeTx.copy().(*DynamicFeeTx)}
+}
+
+func init() {
+	RegisterTxType(registryTestTxType, &TxTypeCodec{
+		NewInner: func() TxInner { return new(registryTestTx) },
+		MarshalData: func(tx *Transaction, enc *TransactionData) {
+			marshalDynamicFeeTx(NewTx(&tx.inner.(*registryTestTx).DynamicFeeTx), enc)
+		},
+		UnmarshalData: func(dec *TransactionData) (TxInner, error) {
+			inner, err := unmarshalDynamicFeeTx(dec)
+			if err != nil {
+				return nil, err
+			}
+			return &registryTestTx{*inner.(*DynamicFeeTx)}, nil
+		},
+		JSONFields:  txTypeCodecs[DynamicFeeTxType].JSONFields,
+		SigningHash: dynamicFeeTxSigningHash,
+	})
+}
+
+func TestRegisteredTxType(t *testing.T) {
+	signer := NewShutterSigner(testChainID)
+	tx := MustSignNewTx(testKey, signer, &registryTestTx{DynamicFeeTx{ChainID: testChainID, Nonce: 1, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(10), Gas: 21000, To: &testRecipient, Value: big.NewInt(1)}})
+
+	// The signing hash is the one of the codec, prefixed with the new type.
+	dynamic := NewTx(&tx.inner.(*registryTestTx).DynamicFeeTx)
+	if h := signer.Hash(tx); h != dynamicFeeTxSigningHash(tx, testChainID) || h == signer.Hash(dynamic) {
+		t.Errorf("wrong signing hash: %v", h)
+	}
+	if from, err := Sender(signer, tx); err != nil || from != testAddr {
+		t.Errorf("wrong sender: have %v (%v), want %v", from, err, testAddr)
+	}
+	if _, err := NewLondonSigner(testChainID).Sender(tx); err != ErrTxTypeNotSupported {
+		t.Errorf("wrong error of london signer: have %v, want %v", err, ErrTxTypeNotSupported)
+	}
+
+	// Binary encoding.
+	enc, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatalf("failed to encode tx: %v", err)
+	}
+	if enc[0] != registryTestTxType {
+		t.Fatalf("wrong type prefix: have %#x, want %#x", enc[0], registryTestTxType)
+	}
+	var dec Transaction
+	if err := dec.UnmarshalBinary(enc); err != nil {
+		t.Fatalf("failed to decode tx: %v", err)
+	}
+	if dec.Type() != registryTestTxType || dec.Hash() != tx.Hash() {
+		t.Errorf("wrong binary decoded tx: type %#x, hash %v", dec.Type(), dec.Hash())
+	}
+
+	// JSON encoding.
+	js, err := json.Marshal(tx)
+	if err != nil {
+		t.Fatalf("failed to encode tx: %v", err)
+	}
+	for _, strict := range []bool{false, true} {
+		var dec Transaction
+		if strict {
+			err = dec.UnmarshalJSONStrict(js, signer)
+		} else {
+			err = dec.UnmarshalJSON(js)
+		}
+		if err != nil {
+			t.Fatalf("strict %t: failed to decode tx: %v", strict, err)
+		}
+		if dec.Type() != registryTestTxType || dec.Hash() != tx.Hash() {
+			t.Errorf("strict %t: wrong JSON decoded tx: type %#x, hash %v", strict, dec.Type(), dec.Hash())
+		}
+	}
+}
+
+func TestRegisterTxTypeInvalid(t *testing.T) {
+	codec := txTypeCodecs[registryTestTxType]
+	incomplete := *codec
+	incomplete.SigningHash = nil
+	noJSONFields := *codec
+	noJSONFields.JSONFields = nil
+
+	tests := []struct {
+		typ   byte
+		codec *TxTypeCodec
+	}{
+		{registryTestTxType, codec}, // duplicate
+		{LegacyTxType, codec},       // built-in
+		{ShutterTxType, codec},      // built-in
+		{BatchTxType, codec},        // built-in
+		{0x80, codec},               // not an EIP-2718 type
+		{0x7d, &incomplete},         // missing signing hash
+		{0x7d, &noJSONFields},       // missing JSON fields
+		{0x7d, &TxTypeCodec{}},      // missing functions
+	}
+	for i, tt := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("test %d: registering type %#x did not panic", i, tt.typ)
+				}
+			}()
+			RegisterTxType(tt.typ, tt.codec)
+		}()
+	}
+	if _, ok := txTypeCodecs[0x7d]; ok {
+		t.Errorf("invalid codec registered")
+	}
+	if txTypeCodecs[ShutterTxType] == codec {
+		t.Errorf("built-in codec replaced")
+	}
+}
@@ -24,7 +24,6 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/params"
 )
 
 var ErrInvalidChainId = errors.New("invalid chain id for signer")
@@ -37,9 +36,15 @@ type sigCache struct {
 }
 
 // MakeSigner returns a Signer based on the given chain config and block number.
-func MakeSigner(config *params.ChainConfig, blockNumber *big.Int) Signer {
+// The embedded upstream chain config must be set. ShutterSigningV2 only takes
+// effect once the Shutter fork is active.
+func MakeSigner(config *ShutterChainConfig, blockNumber *big.Int) Signer {
 	var signer Signer
 	switch {
+	case config.IsShutter(blockNumber) && config.IsShutterSigningV2(blockNumber):
+		signer = NewShutterSignerV2(config.ChainID)
+	case config.IsShutter(blockNumber):
+		signer = NewShutterSigner(config.ChainID)
 	case config.IsLondon(blockNumber):
 		signer = NewLondonSigner(config.ChainID)
 	case config.IsBerlin(blockNumber):
@@ -55,14 +60,21 @@ func MakeSigner(config *params.ChainConfig, blockNumber *big.Int) Signer {
 }
 
 // LatestSigner returns the 'most permissive' Signer available for the given chain
-// configuration. Specifically, this enables support of EIP-155 replay protection and
-// EIP-2930 access list transactions when their respective forks are scheduled to occur at
-// any block number in the chain config.
+// configuration. Specifically, this enables support of EIP-155 replay protection,
+// EIP-2930 access list transactions and Shutter transactions when their respective forks
+// are scheduled to occur at any block number in the chain config.
 //
 // Use this in transaction-handling code where the current block number is unknown. If you
-// have the current block number available, use MakeSigner instead.
-func LatestSigner(config *params.ChainConfig) Signer {
+// have the current block number available, use MakeSigner instead. The embedded
+// upstream chain config must be set.
+func LatestSigner(config *ShutterChainConfig) Signer {
 	if config.ChainID != nil {
+		if config.ShutterBlock != nil {
+			if config.ShutterSigningV2Block != nil {
+				return NewShutterSignerV2(config.ChainID)
+			}
+			return NewShutterSigner(config.ChainID)
+		}
 		if config.LondonBlock != nil {
 			return NewLondonSigner(config.ChainID)
 		}
@@ -78,7 +90,9 @@ func LatestSigner(config *params.ChainConfig) Signer {
 
 // LatestSignerForChainID returns the 'most permissive' Signer available. Specifically,
 // this enables support for EIP-155 replay protection and all implemented EIP-2718
-// transaction types if chainID is non-nil.
+// transaction types, including the Shutter transaction types, if chainID is non-nil.
+// Shutter transactions are signed with the ShutterSigningV2 hash, so their
+// signatures are rejected by chains that have not activated ShutterSigningV2 yet.
 //
 // Use this in transaction-handling code where the current block number and fork
 // configuration are unknown. If you have a ChainConfig, use LatestSigner instead.
@@ -87,7 +101,7 @@ func LatestSignerForChainID(chainID *big.Int) Signer {
 	if chainID == nil {
 		return HomesteadSigner{}
 	}
-	return NewLondonSigner(chainID)
+	return NewShutterSignerV2(chainID)
 }
 
 // SignTx signs the transaction using the given signer and private key.
@@ -170,6 +184,97 @@ type Signer interface {
 	Equal(Signer) bool
 }
 
+// ShutterSigningVersion selects the signing hash used for Shutter transactions.
+type ShutterSigningVersion uint8
+
+const (
+	// ShutterSigningV1 is the original signing hash of Shutter transactions.
+	// It does not cover the L1 block number.
+	ShutterSigningV1 ShutterSigningVersion = iota + 1
+	// ShutterSigningV2 covers every consensus field of Shutter transactions.
+	ShutterSigningV2
+)
+
+type shutterSigner struct {
+	londonSigner
+	version ShutterSigningVersion
+}
+
+// NewShutterSigner returns a signer that accepts
+// - Shutter encrypted transactions and batch transactions,
+// - EIP-1559 dynamic fee transactions,
+// - EIP-2930 access list transactions,
+// - EIP-155 replay protected transactions, and
+// - legacy Homestead transactions.
+//
+// Shutter transactions are signed with the ShutterSigningV1 hash.
+func NewShutterSigner(chainId *big.Int) Signer {
+	return shutterSigner{londonSigner{eip2930Signer{NewEIP155Signer(chainId)}}, ShutterSigningV1}
+}
+
+// NewShutterSignerV2 returns a signer that accepts the same transactions as the
+// signer returned by NewShutterSigner, but signs Shutter transactions with the
+// ShutterSigningV2 hash.
+func NewShutterSignerV2(chainId *big.Int) Signer {
+	return shutterSigner{londonSigner{eip2930Signer{NewEIP155Signer(chainId)}}, ShutterSigningV2}
+}
+
+func (s shutterSigner) Sender(tx *Transaction) (common.Address, error) {
+	switch tx.Type() {
+	case LegacyTxType, AccessListTxType, DynamicFeeTxType:
+		return s.londonSigner.Sender(tx)
+	}
+	if !isTypedTxRegistered(tx.Type()) {
+		return common.Address{}, ErrTxTypeNotSupported
+	}
+	V, R, S := tx.RawSignatureValues()
+	// Shutter txs and other registered typed txs are defined to use 0 and 1 as
+	// their recovery id, add 27 to become equivalent to unprotected Homestead
+	// signatures.
+	V = new(big.Int).Add(V, big.NewInt(27))
+	if tx.ChainId().Cmp(s.chainId) != 0 {
+		return common.Address{}, ErrInvalidChainId
+	}
+	return recoverPlain(s.Hash(tx), R, S, V, true)
+}
+
+func (s shutterSigner) Equal(s2 Signer) bool {
+	x, ok := s2.(shutterSigner)
+	return ok && x.chainId.Cmp(s.chainId) == 0 && x.version == s.version
+}
+
+func (s shutterSigner) SignatureValues(tx *Transaction, sig []byte) (R, S, V *big.Int, err error) {
+	switch tx.Type() {
+	case LegacyTxType, AccessListTxType, DynamicFeeTxType:
+		return s.londonSigner.SignatureValues(tx, sig)
+	}
+	if !isTypedTxRegistered(tx.Type()) {
+		return nil, nil, nil, ErrTxTypeNotSupported
+	}
+	// Check that chain ID of tx matches the signer. We also accept ID zero here,
+	// because it indicates that the chain ID was not specified in the tx.
+	if tx.inner.chainID().Sign() != 0 && tx.inner.chainID().Cmp(s.chainId) != 0 {
+		return nil, nil, nil, ErrInvalidChainId
+	}
+	R, S, _ = decodeSignature(sig)
+	V = big.NewInt(int64(sig[64]))
+	return R, S, V, nil
+}
+
+// Hash returns the hash to be signed by the sender.
+// It does not uniquely identify the transaction.
+func (s shutterSigner) Hash(tx *Transaction) common.Hash {
+	switch tx.Type() {
+	case LegacyTxType, AccessListTxType, DynamicFeeTxType:
+		return s.londonSigner.Hash(tx)
+	case ShutterTxType:
+		if s.version == ShutterSigningV2 {
+			return shutterTxSigningHashV2(tx, s.chainId)
+		}
+	}
+	return typedTxSigningHash(tx, s.chainId)
+}
+
 type londonSigner struct{ eip2930Signer }
 
 // NewLondonSigner returns a signer that accepts
@@ -221,19 +326,7 @@ func (s londonSigner) Hash(tx *Transaction) common.Hash {
 	if tx.Type() != DynamicFeeTxType {
 		return s.eip2930Signer.Hash(tx)
 	}
-	return prefixedRlpHash(
-		tx.Type(),
-		[]interface{}{
-			s.chainId,
-			tx.Nonce(),
-			tx.GasTipCap(),
-			tx.GasFeeCap(),
-			tx.Gas(),
-			tx.To(),
-			tx.Value(),
-			tx.Data(),
-			tx.AccessList(),
-		})
+	return typedTxSigningHash(tx, s.chainId)
 }
 
 type eip2930Signer struct{ EIP155Signer }
@@ -262,7 +355,7 @@ func (s eip2930Signer) Sender(tx *Transaction) (common.Address, error) {
 		}
 		V = new(big.Int).Sub(V, s.chainIdMul)
 		V.Sub(V, big8)
-	case AccessListTxType, ShutterTxType, BatchTxType:
+	case AccessListTxType:
 		// AL txs are defined to use 0 and 1 as their recovery
 		// id, add 27 to become equivalent to unprotected Homestead signatures.
 		V = new(big.Int).Add(V, big.NewInt(27))
@@ -279,7 +372,7 @@ func (s eip2930Signer) SignatureValues(tx *Transaction, sig []byte) (R, S, V *bi
 	switch txdata := tx.inner.(type) {
 	case *LegacyTx:
 		return s.EIP155Signer.SignatureValues(tx, sig)
-	case *AccessListTx, *ShutterTx, *BatchTx:
+	case *AccessListTx:
 		// Check that chain ID of tx matches the signer. We also accept ID zero here,
 		// because it indicates that the chain ID was not specified in the tx.
 		if txdata.chainID().Sign() != 0 && txdata.chainID().Cmp(s.chainId) != 0 {
@@ -308,41 +401,7 @@ func (s eip2930Signer) Hash(tx *Transaction) common.Hash {
 			s.chainId, uint(0), uint(0),
 		})
 	case AccessListTxType:
-		return prefixedRlpHash(
-			tx.Type(),
-			[]interface{}{
-				s.chainId,
-				tx.Nonce(),
-				tx.GasPrice(),
-				tx.Gas(),
-				tx.To(),
-				tx.Value(),
-				tx.Data(),
-				tx.AccessList(),
-			})
-	case ShutterTxType:
-		return prefixedRlpHash(
-			tx.Type(),
-			[]interface{}{
-				s.chainId,
-				tx.BatchIndex(),
-				tx.Nonce(),
-				tx.GasTipCap(),
-				tx.GasFeeCap(),
-				tx.Gas(),
-				tx.EncryptedPayload(),
-			})
-	case BatchTxType:
-		return prefixedRlpHash(
-			tx.Type(),
-			[]interface{}{
-				s.chainId,
-				tx.BatchIndex(),
-				tx.DecryptionKey(),
-				tx.L1BlockNumber(),
-				tx.Timestamp(),
-				tx.Transactions(),
-			})
+		return typedTxSigningHash(tx, s.chainId)
 	default:
 		// This _should_ not happen, but in case someone sends in a bad
 		// json struct via RPC, it's probably more prudent to return an
@@ -352,6 +411,84 @@ func (s eip2930Signer) Hash(tx *Transaction) common.Hash {
 	}
 }
 
+// accessListTxSigningHash returns the signing hash of EIP-2930 access list transactions.
+func accessListTxSigningHash(tx *Transaction, chainID *big.Int) common.Hash {
+	return prefixedRlpHash(
+		tx.Type(),
+		[]interface{}{
+			chainID,
+			tx.Nonce(),
+			tx.GasPrice(),
+			tx.Gas(),
+			tx.To(),
+			tx.Value(),
+			tx.Data(),
+			tx.AccessList(),
+		})
+}
+
+// dynamicFeeTxSigningHash returns the signing hash of EIP-1559 dynamic fee transactions.
+func dynamicFeeTxSigningHash(tx *Transaction, chainID *big.Int) common.Hash {
+	return prefixedRlpHash(
+		tx.Type(),
+		[]interface{}{
+			chainID,
+			tx.Nonce(),
+			tx.GasTipCap(),
+			tx.GasFeeCap(),
+			tx.Gas(),
+			tx.To(),
+			tx.Value(),
+			tx.Data(),
+			tx.AccessList(),
+		})
+}
+
+// shutterTxSigningHash returns the ShutterSigningV1 hash of Shutter transactions.
+func shutterTxSigningHash(tx *Transaction, chainID *big.Int) common.Hash {
+	return prefixedRlpHash(
+		tx.Type(),
+		[]interface{}{
+			chainID,
+			tx.BatchIndex(),
+			tx.Nonce(),
+			tx.GasTipCap(),
+			tx.GasFeeCap(),
+			tx.Gas(),
+			tx.EncryptedPayload(),
+		})
+}
+
+// shutterTxSigningHashV2 returns the ShutterSigningV2 hash of Shutter transactions.
+func shutterTxSigningHashV2(tx *Transaction, chainID *big.Int) common.Hash {
+	return prefixedRlpHash(
+		tx.Type(),
+		[]interface{}{
+			chainID,
+			tx.Nonce(),
+			tx.GasTipCap(),
+			tx.GasFeeCap(),
+			tx.Gas(),
+			tx.EncryptedPayload(),
+			tx.BatchIndex(),
+			tx.L1BlockNumber(),
+		})
+}
+
+// batchTxSigningHash returns the signing hash of batch transactions.
+func batchTxSigningHash(tx *Transaction, chainID *big.Int) common.Hash {
+	return prefixedRlpHash(
+		tx.Type(),
+		[]interface{}{
+			chainID,
+			tx.BatchIndex(),
+			tx.DecryptionKey(),
+			tx.L1BlockNumber(),
+			tx.Timestamp(),
+			tx.Transactions(),
+		})
+}
+
 // EIP155Signer implements Signer using the EIP-155 rules. This accepts transactions which
 // are replay-protected as well as unprotected homestead transactions.
 type EIP155Signer struct {
@@ -0,0 +1,114 @@
+package types
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// signingHashTestTx is the Shutter transaction of the signing hash test vectors.
+var signingHashTestTx = NewTx(&ShutterTx{
+	ChainID:          big.NewInt(1),
+	Nonce:            3,
+	GasTipCap:        big.NewInt(1000000000),
+	GasFeeCap:        big.NewInt(2000000000),
+	Gas:              100000,
+	EncryptedPayload: []byte{0xde, 0xad, 0xbe, 0xef},
+	BatchIndex:       42,
+	L1BlockNumber:    1234,
+})
+
+func TestShutterTxSigningHashVectors(t *testing.T) {
+	tests := []struct {
+		signer Signer
+		want   common.Hash
+	}{
+		// keccak256(0x50 || rlp([chainID, batchIndex, nonce, gasTipCap, gasFeeCap, gas, encryptedPayload]))
+		{NewShutterSigner(big.NewInt(1)), common.HexToHash("0xb47bbfee6a99f0b1e6b2e37c60ea78ce3069d87050a9d3d3af567f7f36bf7e35")},
+		// keccak256(0x50 || rlp([chainID, nonce, gasTipCap, gasFeeCap, gas, encryptedPayload, batchIndex, l1BlockNumber]))
+		{NewShutterSignerV2(big.NewInt(1)), common.HexToHash("0x753b3734598e1c0641fc6559115700e5a06ec22faeff8df1f0d20d8175b28d9a")},
+	}
+	for i, tt := range tests {
+		if have := tt.signer.Hash(signingHashTestTx); have != tt.want {
+			t.Errorf("test %d: wrong signing hash: have %v, want %v", i, have, tt.want)
+		}
+	}
+}
+
+func TestShutterSigningVersionActivation(t *testing.T) {
+	config := &ShutterChainConfig{
+		ChainConfig: &params.ChainConfig{
+			ChainID:        testChainID,
+			HomesteadBlock: big.NewInt(0),
+			EIP155Block:    big.NewInt(0),
+			BerlinBlock:    big.NewInt(0),
+			LondonBlock:    big.NewInt(0),
+		},
+		ShutterBlock:          big.NewInt(10),
+		ShutterSigningV2Block: big.NewInt(20),
+	}
+	v1 := newTestShutterTx(t, testShutterPay, 1, 0)
+	v2, err := SignNewEncryptedShutterTx(testKey, NewShutterSignerV2(testChainID), testShutterPay, testEonKey, 1, 7, 0, big.NewInt(1), big.NewInt(10), 100000, AESTestScheme{})
+	if err != nil {
+		t.Fatalf("failed to sign tx: %v", err)
+	}
+
+	// V1 signatures recover through MakeSigner until the V2 fork, and V2
+	// signatures afterwards.
+	for _, number := range []int64{10, 19} {
+		if from, err := Sender(MakeSigner(config, big.NewInt(number)), v1); err != nil || from != testAddr {
+			t.Errorf("block %d: wrong sender of v1 tx: have %v (%v), want %v", number, from, err, testAddr)
+		}
+	}
+	for _, number := range []int64{20, 21} {
+		if from, err := Sender(MakeSigner(config, big.NewInt(number)), v2); err != nil || from != testAddr {
+			t.Errorf("block %d: wrong sender of v2 tx: have %v (%v), want %v", number, from, err, testAddr)
+		}
+		if from, err := Sender(MakeSigner(config, big.NewInt(number)), v1); err == nil && from == testAddr {
+			t.Errorf("block %d: v1 signature accepted after v2 fork", number)
+		}
+	}
+	if !LatestSignerForChainID(testChainID).Equal(NewShutterSignerV2(testChainID)) {
+		t.Errorf("LatestSignerForChainID does not sign with ShutterSigningV2")
+	}
+	if !LatestSigner(config).Equal(NewShutterSignerV2(testChainID)) {
+		t.Errorf("LatestSigner does not sign with ShutterSigningV2")
+	}
+}
+
+func TestMakeSignerBeforeShutterFork(t *testing.T) {
+	config := &ShutterChainConfig{
+		ChainConfig: &params.ChainConfig{
+			ChainID:        testChainID,
+			HomesteadBlock: big.NewInt(0),
+			EIP155Block:    big.NewInt(0),
+			BerlinBlock:    big.NewInt(0),
+			LondonBlock:    big.NewInt(0),
+		},
+		ShutterBlock: big.NewInt(10),
+		// Misconfigured before the Shutter fork, which must not enable the
+		// Shutter transaction types early.
+		ShutterSigningV2Block: big.NewInt(5),
+	}
+	shutter, err := SignNewEncryptedShutterTx(testKey, NewShutterSignerV2(testChainID), testShutterPay, testEonKey, 1, 7, 0, big.NewInt(1), big.NewInt(10), 100000, AESTestScheme{})
+	if err != nil {
+		t.Fatalf("failed to sign tx: %v", err)
+	}
+	batch := MustSignNewTx(testKey, NewShutterSignerV2(testChainID), &BatchTx{ChainID: testChainID, BatchIndex: 1, Timestamp: big.NewInt(0)})
+
+	for _, number := range []int64{0, 5, 9} {
+		signer := MakeSigner(config, big.NewInt(number))
+		for _, tx := range []*Transaction{shutter, batch} {
+			if _, err := signer.Sender(tx); err != ErrTxTypeNotSupported {
+				t.Errorf("block %d, type %#x: wrong error: have %v, want %v", number, tx.Type(), err, ErrTxTypeNotSupported)
+			}
+		}
+	}
+	for _, tx := range []*Transaction{shutter, batch} {
+		if from, err := MakeSigner(config, big.NewInt(10)).Sender(tx); err != nil || from != testAddr {
+			t.Errorf("type %#x: wrong sender at fork: have %v (%v), want %v", tx.Type(), from, err, testAddr)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package types
+
+import (
+	"errors"
+	"io"
+
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var errUnexpectedDecryption = errors.New("decryption fields in stored non-Shutter transaction")
+
+// TransactionForStorage is a wrapper around a Transaction that encodes the
+// decrypted payload and the decryption status of Shutter transactions along
+// with the canonical encoding, as opposed to only the canonical encoding
+// originally. Decoding it yields a transaction with the same hash.
+type TransactionForStorage Transaction
+
+// storedTransactionRLP is the storage encoding of a transaction.
+type storedTransactionRLP struct {
+	Tx               []byte           // Canonical encoding as returned by MarshalBinary
+	Payload          []byte           `rlp:"optional"` // Encoded decrypted payload, if any
+	DecryptionStatus DecryptionStatus `rlp:"optional"`
+}
+
+// EncodeRLP implements rlp.Encoder.
+func (tx *TransactionForStorage) EncodeRLP(w io.Writer) error {
+	canonical, err := (*Transaction)(tx).MarshalBinary()
+	if err != nil {
+		return err
+	}
+	enc := &storedTransactionRLP{Tx: canonical}
+	if inner, ok := tx.inner.(*ShutterTx); ok {
+		if inner.Payload != nil {
+			if enc.Payload, err = inner.Payload.Encode(); err != nil {
+				return err
+			}
+		}
+		enc.DecryptionStatus = inner.DecryptionStatus
+	}
+	return rlp.Encode(w, enc)
+}
+
+// DecodeRLP implements rlp.Decoder.
+func (tx *TransactionForStorage) DecodeRLP(s *rlp.Stream) error {
+	var dec storedTransactionRLP
+	if err := s.Decode(&dec); err != nil {
+		return err
+	}
+	var decoded Transaction
+	if err := decoded.UnmarshalBinary(dec.Tx); err != nil {
+		return err
+	}
+	if len(dec.Payload) > 0 || dec.DecryptionStatus != DecryptionStatusNone {
+		inner, ok := decoded.inner.(*ShutterTx)
+		if !ok {
+			return errUnexpectedDecryption
+		}
+		if len(dec.Payload) > 0 {
+			payload, err := DecodeShutterPayload(dec.Payload)
+			if err != nil {
+				return err
+			}
+			inner.Payload = payload
+		}
+		inner.DecryptionStatus = dec.DecryptionStatus
+	}
+	(*Transaction)(tx).setDecoded(decoded.inner, len(dec.Tx))
+	return nil
+}
@@ -0,0 +1,89 @@
+package types
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func TestTransactionForStorageRoundTrip(t *testing.T) {
+	signer := NewShutterSigner(testChainID)
+	encrypted := newTestShutterTx(t, testShutterPay, 3, 0)
+	decrypted, err := encrypted.Decrypt(AESTestScheme{}.DecryptionKey(testEonKey, 3), AESTestScheme{})
+	if err != nil {
+		t.Fatalf("failed to decrypt: %v", err)
+	}
+	failedInner := encrypted.inner.copy().(*ShutterTx)
+	failedInner.DecryptionStatus = DecryptionStatusDecryptionFailed
+	failed := &Transaction{inner: failedInner}
+
+	tests := []struct {
+		name    string
+		tx      *Transaction
+		payload *ShutterPayload
+		status  DecryptionStatus
+	}{
+		{name: "legacy", tx: MustSignNewTx(testKey, signer, &LegacyTx{Nonce: 1, GasPrice: big.NewInt(1), Gas: 21000, To: &testRecipient, Value: big.NewInt(1)})},
+		{name: "access list", tx: MustSignNewTx(testKey, signer, &AccessListTx{ChainID: testChainID, Nonce: 2, GasPrice: big.NewInt(1), Gas: 21000, To: &testRecipient, Value: big.NewInt(1)})},
+		{name: "dynamic fee", tx: MustSignNewTx(testKey, signer, &DynamicFeeTx{ChainID: testChainID, Nonce: 3, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(1), Gas: 21000, To: &testRecipient, Value: big.NewInt(1)})},
+		{name: "batch", tx: newTestBatchTx(3, mustMarshalBinary(t, encrypted))},
+		{name: "encrypted shutter", tx: encrypted},
+		{name: "decrypted shutter", tx: decrypted, payload: testShutterPay, status: DecryptionStatusDecrypted},
+		{name: "undecryptable shutter", tx: failed, status: DecryptionStatusDecryptionFailed},
+	}
+	for _, tt := range tests {
+		enc, err := rlp.EncodeToBytes((*TransactionForStorage)(tt.tx))
+		if err != nil {
+			t.Errorf("%s: failed to encode: %v", tt.name, err)
+			continue
+		}
+		var dec TransactionForStorage
+		if err := rlp.DecodeBytes(enc, &dec); err != nil {
+			t.Errorf("%s: failed to decode: %v", tt.name, err)
+			continue
+		}
+		tx := (*Transaction)(&dec)
+		if tx.Type() != tt.tx.Type() {
+			t.Errorf("%s: wrong type: have %d, want %d", tt.name, tx.Type(), tt.tx.Type())
+		}
+		if tx.Hash() != tt.tx.Hash() {
+			t.Errorf("%s: wrong hash: have %v, want %v", tt.name, tx.Hash(), tt.tx.Hash())
+		}
+		if status := tx.DecryptionStatus(); status != tt.status {
+			t.Errorf("%s: wrong decryption status: have %v, want %v", tt.name, status, tt.status)
+		}
+		if tt.payload == nil {
+			if inner, ok := tx.inner.(*ShutterTx); ok && inner.Payload != nil {
+				t.Errorf("%s: unexpected payload: %v", tt.name, inner.Payload)
+			}
+			continue
+		}
+		if to := tx.To(); to == nil || *to != *tt.payload.To {
+			t.Errorf("%s: wrong recipient: have %v, want %v", tt.name, to, tt.payload.To)
+		}
+		if value := tx.Value(); value.Cmp(tt.payload.Value) != 0 {
+			t.Errorf("%s: wrong value: have %v, want %v", tt.name, value, tt.payload.Value)
+		}
+		if data := tx.Data(); !bytes.Equal(data, tt.payload.Data) {
+			t.Errorf("%s: wrong data: have %x, want %x", tt.name, data, tt.payload.Data)
+		}
+	}
+}
+
+func TestTransactionForStorageUnexpectedDecryption(t *testing.T) {
+	tx := MustSignNewTx(testKey, NewShutterSigner(testChainID), &LegacyTx{Nonce: 1, GasPrice: big.NewInt(1), Gas: 21000, To: &testRecipient})
+	payload, err := testShutterPay.Encode()
+	if err != nil {
+		t.Fatalf("failed to encode payload: %v", err)
+	}
+	enc, err := rlp.EncodeToBytes(&storedTransactionRLP{Tx: mustMarshalBinary(t, tx), Payload: payload, DecryptionStatus: DecryptionStatusDecrypted})
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	var dec TransactionForStorage
+	if err := rlp.DecodeBytes(enc, &dec); err != errUnexpectedDecryption {
+		t.Errorf("wrong error: have %v, want %v", err, errUnexpectedDecryption)
+	}
+}